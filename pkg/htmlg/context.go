@@ -0,0 +1,58 @@
+package htmlg
+
+import "context"
+
+type ctxKey int
+
+const (
+	ctxKeyCSPNonce ctxKey = iota
+	ctxKeyCSRFField
+)
+
+// ContextWithCSPNonce returns a copy of the context holding the given Content-Security-Policy nonce.
+func ContextWithCSPNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, ctxKeyCSPNonce, nonce)
+}
+
+// CSPNonceFromContext returns the Content-Security-Policy nonce stored in the context (or an empty string).
+func CSPNonceFromContext(ctx context.Context) string {
+	nonce, _ := ctx.Value(ctxKeyCSPNonce).(string)
+	return nonce
+}
+
+// SetCSPNonce sets the nonce attribute to the nonce stored in the context (if any).
+// Use it on inline <script> and <style> elements so they are allowed by the Content-Security-Policy.
+func SetCSPNonce(ctx context.Context) Modifier {
+	return func(e *Element) {
+		if nonce := CSPNonceFromContext(ctx); nonce != "" {
+			e.Apply(SetAttr([2]string{"nonce", nonce}))
+		}
+	}
+}
+
+type csrfField struct{ name, token string }
+
+// ContextWithCSRFToken returns a copy of the context holding the given CSRF token
+// and the name of the form field it should be submitted with.
+func ContextWithCSRFToken(ctx context.Context, fieldName, token string) context.Context {
+	return context.WithValue(ctx, ctxKeyCSRFField, csrfField{name: fieldName, token: token})
+}
+
+// CSRFTokenFromContext returns the CSRF form field name and token stored in the context (or empty strings).
+func CSRFTokenFromContext(ctx context.Context) (fieldName, token string) {
+	field, _ := ctx.Value(ctxKeyCSRFField).(csrfField)
+	return field.name, field.token
+}
+
+// AddCSRFTokenInput adds a hidden input holding the CSRF token stored in the context (if any).
+// Use it on <form> elements submitted with an unsafe method (for ex: POST).
+func AddCSRFTokenInput(ctx context.Context) Modifier {
+	return func(e *Element) {
+		name, token := CSRFTokenFromContext(ctx)
+		if token == "" {
+			return
+		}
+		input := Create("input", SetAttrs([2]string{"type", "hidden"}, [2]string{"name", name}, [2]string{"value", token}))
+		e.Apply(AddChild(input))
+	}
+}
@@ -1,6 +1,7 @@
 package htmlg
 
 import (
+	"context"
 	"testing"
 
 	"github.com/ejuju/go-utils/pkg/cssg"
@@ -48,6 +49,22 @@ func TestHTMLGeneration(t *testing.T) {
 				input:          Create("h1", Style(cssg.DeclarationGroup{{"color", "black"}, {"margin", "16px"}}...)),
 				expectedOutput: `<h1 style="color: black; margin: 16px"></h1>`,
 			},
+			{
+				description:    "can set the CSP nonce from the context",
+				input:          Create("script", SetCSPNonce(ContextWithCSPNonce(context.Background(), "abc"))),
+				expectedOutput: `<script nonce="abc"></script>`,
+			},
+			{
+				description: "can add a hidden CSRF token input from the context",
+				input: Create("form", WithString("<button></button>"),
+					AddCSRFTokenInput(ContextWithCSRFToken(context.Background(), "csrf_token", "abc"))),
+				expectedOutput: `<form><button></button><input type="hidden" name="csrf_token" value="abc"></form>`,
+			},
+			{
+				description:    "can ignore missing CSRF token",
+				input:          Create("form", AddCSRFTokenInput(context.Background())),
+				expectedOutput: `<form></form>`,
+			},
 		}
 
 		for _, test := range tests {
@@ -0,0 +1,75 @@
+package kv
+
+import "fmt"
+
+// Batch buffers write operations so they can be appended to the database file at once.
+// Use DB.NewBatch to instanciate a batch and DB.WriteBatch to apply it.
+type Batch struct {
+	format *Format
+	rows   []byte
+	ops    []batchOp
+}
+
+type batchOp struct {
+	key    string
+	size   int
+	delete bool
+}
+
+// NewBatch returns an empty batch using the database format.
+func (db *DB) NewBatch() *Batch { return &Batch{format: db.format} }
+
+// Put buffers a put operation (key-only if v is nil).
+func (b *Batch) Put(k, v []byte) error {
+	var row []byte
+	var err error
+	if v == nil {
+		row, err = b.format.Encode(WriteOpPutKey, k, nil)
+	} else {
+		row, err = b.format.Encode(WriteOpPutKeyValue, k, v)
+	}
+	if err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+	b.rows = append(b.rows, row...)
+	b.ops = append(b.ops, batchOp{key: string(k), size: len(row)})
+	return nil
+}
+
+// Delete buffers a delete operation.
+func (b *Batch) Delete(k []byte) error {
+	row, err := b.format.Encode(WriteOpDelete, k, nil)
+	if err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+	b.rows = append(b.rows, row...)
+	b.ops = append(b.ops, batchOp{key: string(k), size: len(row), delete: true})
+	return nil
+}
+
+// Reports the number of buffered operations.
+func (b *Batch) Len() int { return len(b.ops) }
+
+// Discards all buffered operations.
+func (b *Batch) Reset() { b.rows, b.ops = b.rows[:0], b.ops[:0] }
+
+// WriteBatch appends all rows of the batch to the file in a single write
+// and only updates the in-memory file refs once the write succeeded.
+func (db *DB) WriteBatch(b *Batch) error {
+	if len(b.ops) == 0 {
+		return nil
+	}
+	_, err := db.fileWO.Write(b.rows)
+	if err != nil {
+		return fmt.Errorf("append batch to file: %w", err)
+	}
+	for _, op := range b.ops {
+		if op.delete {
+			delete(db.fileRefs, op.key)
+		} else {
+			db.fileRefs[op.key] = FileRef{Offset: db.fileOffset, Size: op.size}
+		}
+		db.fileOffset += op.size
+	}
+	return nil
+}
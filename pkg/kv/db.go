@@ -27,7 +27,10 @@ type FileRef struct {
 // Opens underlying file handles for the given path (for reading and writing data to a file on disk)
 // and extract initial data.
 func NewDB(fpath string, chars *Format) (*DB, error) {
-	var err error
+	err := chars.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %w", err)
+	}
 	db := &DB{format: chars, fileRefs: make(map[string]FileRef)}
 
 	// Open file (we need one read-only and one write-only handle)
@@ -74,8 +77,10 @@ func (db *DB) FilePath() string { return db.fileRO.Name() }
 func (db *DB) Sync() error { return db.fileWO.Sync() }
 
 // Calls lock on the underlying mutex.
-func (db *DB) Lock()   { db.mu.Lock() }
-func (db *DB) Unlock() { db.mu.Unlock() }
+func (db *DB) Lock()    { db.mu.Lock() }
+func (db *DB) Unlock()  { db.mu.Unlock() }
+func (db *DB) RLock()   { db.mu.RLock() }
+func (db *DB) RUnlock() { db.mu.RUnlock() }
 
 // Put set a key or key-value pair in the database.
 func (db *DB) Put(k, v []byte) error {
@@ -113,8 +118,9 @@ func (db *DB) Delete(k []byte) error {
 	if err != nil {
 		return fmt.Errorf("file write: %w", err)
 	}
-	// Remove file ref
+	// Remove file ref (the delete row still takes up space on file)
 	delete(db.fileRefs, string(k))
+	db.fileOffset += len(b)
 	return err
 }
 
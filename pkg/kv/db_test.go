@@ -0,0 +1,91 @@
+package kv
+
+import (
+	"bytes"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func TestDB(t *testing.T) {
+	t.Run("should match a map model after random writes and reopens", func(t *testing.T) {
+		formats := map[string]*Format{
+			"default": DefaultFormat,
+			"custom": {
+				PutKey:      'p',
+				PutKeyValue: 'k',
+				Delete:      'd',
+				KeyPrefix:   ':',
+				ValuePrefix: '=',
+				RowEnd:      ';',
+			},
+		}
+
+		for name, format := range formats {
+			t.Run(name, func(t *testing.T) {
+				rng := rand.New(rand.NewSource(1))
+				alphabet := []byte{'a', 'b', ' ', '\r', '\n', '\t', '-', '=', '!', ':', ';', 'p', 'k', 'd'}
+				randBytes := func(maxLength int) []byte {
+					out := make([]byte, rng.Intn(maxLength+1))
+					for i := range out {
+						out[i] = alphabet[rng.Intn(len(alphabet))]
+					}
+					return out
+				}
+
+				fpath := filepath.Join(t.TempDir(), "test.db")
+				db, err := NewDB(fpath, format)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer func() { db.Close() }()
+
+				model := map[string][]byte{} // nil value for key-only rows
+				for i := 0; i < 2000; i++ {
+					k := randBytes(4)
+					switch rng.Intn(10) {
+					case 0, 1, 2, 3:
+						v := randBytes(6)
+						if db.Put(k, v) == nil {
+							model[string(k)] = v
+						}
+					case 4, 5:
+						if db.Put(k, nil) == nil {
+							model[string(k)] = nil
+						}
+					case 6, 7, 8:
+						if db.Delete(k) == nil {
+							delete(model, string(k))
+						}
+					case 9:
+						if err := db.Close(); err != nil {
+							t.Fatal(err)
+						}
+						db, err = NewDB(fpath, format)
+						if err != nil {
+							t.Fatalf("step %d: reopen: %s", i, err)
+						}
+						checkDBMatchesModel(t, db, model)
+					}
+				}
+				checkDBMatchesModel(t, db, model)
+			})
+		}
+	})
+}
+
+func checkDBMatchesModel(t *testing.T, db *DB, model map[string][]byte) {
+	t.Helper()
+	if db.Count() != len(model) {
+		t.Fatalf("want %d keys but got %d", len(model), db.Count())
+	}
+	for k, want := range model {
+		got, err := db.Get([]byte(k))
+		if err != nil {
+			t.Fatalf("get key %q: %s", k, err)
+		}
+		if (want == nil) != (got == nil) || !bytes.Equal(want, got) {
+			t.Fatalf("key %q: want value %q but got %q", k, want, got)
+		}
+	}
+}
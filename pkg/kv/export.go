@@ -0,0 +1,206 @@
+package kv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ExportFormat represents a file format used to export and import data.
+type ExportFormat string
+
+const (
+	ExportFormatJSONLines ExportFormat = "jsonl" // One JSON object per line: {"key":"k","value":"v"} (value is null for key-only rows)
+	ExportFormatCSV       ExportFormat = "csv"   // "key,value" header, followed by one record per row (one field for key-only rows)
+)
+
+var (
+	ErrUnknownExportFormat = errors.New("unknown export format")
+	ErrNotUTF8             = errors.New("not valid UTF-8")
+)
+
+// Exported row representation for JSON Lines.
+type jsonRow struct {
+	Key   string  `json:"key"`
+	Value *string `json:"value"`
+}
+
+var csvHeader = []string{"key", "value"}
+
+// Export writes all rows with the given key prefix to w, sorted by key.
+// An empty prefix exports all rows.
+// Keys and values must be valid UTF-8 (binary values would be corrupted), ErrNotUTF8 is returned otherwise.
+func (db *DB) Export(w io.Writer, format ExportFormat, prefix []byte) error {
+	keys := []string{}
+	db.ForEachKey(func(k []byte) (stop bool) {
+		if bytes.HasPrefix(k, prefix) {
+			keys = append(keys, string(k))
+		}
+		return false
+	})
+	sort.Strings(keys)
+
+	var writeRow func(k string, v []byte) error
+	var flush func() error
+	switch format {
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownExportFormat, format)
+	case ExportFormatJSONLines:
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+		writeRow = func(k string, v []byte) error {
+			row := jsonRow{Key: k}
+			if v != nil {
+				s := string(v)
+				row.Value = &s
+			}
+			return enc.Encode(row)
+		}
+		flush = bw.Flush
+	case ExportFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return fmt.Errorf("write header: %w", err)
+		}
+		writeRow = func(k string, v []byte) error {
+			if v == nil {
+				return cw.Write([]string{k})
+			}
+			return cw.Write([]string{k, string(v)})
+		}
+		flush = func() error { cw.Flush(); return cw.Error() }
+	}
+
+	for _, k := range keys {
+		v, err := db.Get([]byte(k))
+		if err != nil {
+			return err
+		}
+		if !utf8.ValidString(k) || !utf8.Valid(v) {
+			return fmt.Errorf("export row %q: %w", k, ErrNotUTF8)
+		}
+		err = writeRow(k, v)
+		if err != nil {
+			return fmt.Errorf("write row %q: %w", k, err)
+		}
+	}
+	return flush()
+}
+
+// LineError reports an error that occured when importing a specific line.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (le *LineError) Error() string { return fmt.Sprintf("line %d: %s", le.Line, le.Err) }
+func (le *LineError) Unwrap() error { return le.Err }
+
+// ImportErrors is returned by Import when one or more lines are invalid.
+type ImportErrors []*LineError
+
+func (ie ImportErrors) Error() string {
+	msg := []string{}
+	for _, err := range ie {
+		msg = append(msg, err.Error())
+	}
+	return strings.Join(msg, ", ")
+}
+
+// Import reads rows from r and puts them in the database.
+// All rows are written in a single batch: if any line is invalid, nothing is written
+// and an ImportErrors value reporting each invalid line is returned.
+func (db *DB) Import(r io.Reader, format ExportFormat) error {
+	b := db.NewBatch()
+	var lineErrs ImportErrors
+	var err error
+	switch format {
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownExportFormat, format)
+	case ExportFormatJSONLines:
+		lineErrs, err = importJSONLines(r, b)
+	case ExportFormatCSV:
+		lineErrs, err = importCSV(r, b)
+	}
+	if err != nil {
+		return err
+	}
+	if len(lineErrs) > 0 {
+		return lineErrs
+	}
+	return db.WriteBatch(b)
+}
+
+func importJSONLines(r io.Reader, b *Batch) (ImportErrors, error) {
+	var lineErrs ImportErrors
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		raw, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("read line %d: %w", line, err)
+		}
+		if len(bytes.TrimSpace(raw)) > 0 {
+			row := jsonRow{}
+			if decodeErr := json.Unmarshal(raw, &row); decodeErr != nil {
+				lineErrs = append(lineErrs, &LineError{Line: line, Err: decodeErr})
+			} else if putErr := putImportedRow(b, row.Key, row.Value); putErr != nil {
+				lineErrs = append(lineErrs, &LineError{Line: line, Err: putErr})
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return lineErrs, nil
+		}
+	}
+}
+
+func importCSV(r io.Reader, b *Batch) (ImportErrors, error) {
+	var lineErrs ImportErrors
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1 // key-only rows have a single field
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if len(header) != 2 || header[0] != csvHeader[0] || header[1] != csvHeader[1] {
+		return nil, fmt.Errorf("invalid header: %q", header)
+	}
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return lineErrs, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			lineErrs = append(lineErrs, &LineError{Line: parseErr.Line, Err: parseErr.Err})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		switch len(record) {
+		default:
+			lineErrs = append(lineErrs, &LineError{Line: line, Err: fmt.Errorf("want 1 or 2 fields but got %d", len(record))})
+		case 1:
+			err = putImportedRow(b, record[0], nil)
+		case 2:
+			err = putImportedRow(b, record[0], &record[1])
+		}
+		if err != nil {
+			lineErrs = append(lineErrs, &LineError{Line: line, Err: err})
+		}
+	}
+}
+
+func putImportedRow(b *Batch, k string, v *string) error {
+	if v == nil {
+		return b.Put([]byte(k), nil)
+	}
+	return b.Put([]byte(k), []byte(*v))
+}
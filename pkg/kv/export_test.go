@@ -0,0 +1,100 @@
+package kv
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+	newDB := func(t *testing.T) *DB {
+		db, err := NewDB(filepath.Join(t.TempDir(), "test.db"), DefaultFormat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	for _, format := range []ExportFormat{ExportFormatJSONLines, ExportFormatCSV} {
+		t.Run("can export and import rows as "+string(format), func(t *testing.T) {
+			src := newDB(t)
+			rows := map[string][]byte{
+				"user:1":  []byte("alice, \"admin\""),
+				"user:2":  []byte(""),
+				"user:3":  nil,
+				"other:1": []byte("not exported"),
+			}
+			for k, v := range rows {
+				if err := src.Put([]byte(k), v); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			buf := &bytes.Buffer{}
+			if err := src.Export(buf, format, []byte("user:")); err != nil {
+				t.Fatal(err)
+			}
+			dst := newDB(t)
+			if err := dst.Import(buf, format); err != nil {
+				t.Fatal(err)
+			}
+
+			delete(rows, "other:1")
+			checkDBMatchesModel(t, dst, rows)
+		})
+	}
+
+	t.Run("should report invalid lines and write nothing", func(t *testing.T) {
+		tests := []struct {
+			format    ExportFormat
+			input     string
+			wantLines []int
+		}{
+			{
+				format:    ExportFormatJSONLines,
+				input:     "{\"key\":\"a\",\"value\":\"1\"}\n{\"key\":\"\"}\n\n{invalid\n{\"key\":\"b\",\"value\":null}\n",
+				wantLines: []int{2, 4},
+			},
+			{
+				format:    ExportFormatCSV,
+				input:     "key,value\na,1\n\"in valid\",1\nb\nc,1,2\n",
+				wantLines: []int{3, 5},
+			},
+		}
+
+		for _, test := range tests {
+			db := newDB(t)
+			err := db.Import(strings.NewReader(test.input), test.format)
+			var importErrs ImportErrors
+			if !errors.As(err, &importErrs) {
+				t.Fatalf("%s: want ImportErrors but got %v", test.format, err)
+			}
+			gotLines := []int{}
+			for _, lineErr := range importErrs {
+				gotLines = append(gotLines, lineErr.Line)
+			}
+			if len(gotLines) != len(test.wantLines) || gotLines[0] != test.wantLines[0] || gotLines[1] != test.wantLines[1] {
+				t.Fatalf("%s: want errors on lines %v but got %v", test.format, test.wantLines, gotLines)
+			}
+			if db.Count() != 0 {
+				t.Fatalf("%s: want no keys but got %d", test.format, db.Count())
+			}
+		}
+	})
+
+	t.Run("should refuse to export binary values", func(t *testing.T) {
+		db := newDB(t)
+		if err := db.Put([]byte("bin"), []byte{0xff, 0xfe}); err != nil {
+			t.Fatal(err)
+		}
+		for _, format := range []ExportFormat{ExportFormatJSONLines, ExportFormatCSV} {
+			err := db.Export(&bytes.Buffer{}, format, nil)
+			if !errors.Is(err, ErrNotUTF8) {
+				t.Fatalf("%s: want error %q but got %v", format, ErrNotUTF8, err)
+			}
+		}
+	})
+}
@@ -3,6 +3,7 @@ package kv
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 )
@@ -31,6 +32,20 @@ var DefaultFormat = &Format{
 	RowEnd:      '\n',
 }
 
+// Validate reports whether the format characters allow rows to be encoded and parsed back unambiguously.
+// Write-op characters must be distinct and no character can be the same as the row end.
+func (chars *Format) Validate() error {
+	if chars.PutKey == chars.PutKeyValue || chars.PutKey == chars.Delete || chars.PutKeyValue == chars.Delete {
+		return fmt.Errorf("write-op characters must be distinct: %q %q %q", chars.PutKey, chars.PutKeyValue, chars.Delete)
+	}
+	for _, c := range []byte{chars.PutKey, chars.PutKeyValue, chars.Delete, chars.KeyPrefix, chars.ValuePrefix} {
+		if c == chars.RowEnd {
+			return fmt.Errorf("character %q is the same as row end", c)
+		}
+	}
+	return nil
+}
+
 func (chars *Format) Encode(kind WriteOp, k, v []byte) ([]byte, error) {
 	if len(k) == 0 {
 		return nil, ErrKeyEmpty
@@ -38,14 +53,14 @@ func (chars *Format) Encode(kind WriteOp, k, v []byte) ([]byte, error) {
 	if (kind == WriteOpDelete || kind == WriteOpPutKey) && len(v) > 0 {
 		return nil, fmt.Errorf("row %q must receive nil value", kind)
 	}
-	if (kind == WriteOpDelete || kind == WriteOpPutKey) && bytes.IndexByte(k, chars.RowEnd) != -1 {
+	if bytes.IndexByte(k, chars.RowEnd) != -1 {
 		return nil, fmt.Errorf("invalid key contains row end %q: %q", chars.RowEnd, k)
 	}
 	if kind == WriteOpPutKeyValue && bytes.IndexByte(k, chars.ValuePrefix) != -1 {
 		return nil, fmt.Errorf("invalid key contains value prefix %q: %q", chars.ValuePrefix, k)
 	}
 	if kind == WriteOpPutKeyValue && bytes.IndexByte(v, chars.RowEnd) != -1 {
-		return nil, fmt.Errorf("invalid value contains row end %q: %q", chars.RowEnd, k)
+		return nil, fmt.Errorf("invalid value contains row end %q: %q", chars.RowEnd, v)
 	}
 
 	out := []byte{}
@@ -129,19 +144,25 @@ func (chars *Format) ParseRowFromBytes(b []byte) (WriteOp, []byte, []byte, error
 
 func extractFileRefs(r io.Reader, format *Format, refs map[string]FileRef) (int, error) {
 	offset := 0
-	s := bufio.NewScanner(r)
-	for s.Scan() {
-		row := append(s.Bytes(), '\n')
+	br := bufio.NewReader(r)
+	for {
+		row, err := br.ReadBytes(format.RowEnd)
+		if errors.Is(err, io.EOF) && len(row) == 0 {
+			return offset, nil
+		} else if errors.Is(err, io.EOF) {
+			return offset, fmt.Errorf("incomplete row at offset %d: %q", offset, row)
+		} else if err != nil {
+			return offset, err
+		}
 		writeOp, k, _, err := format.ParseRowFromBytes(row)
 		if err != nil {
-			return offset, err
+			return offset, fmt.Errorf("row at offset %d: %w", offset, err)
 		}
 		if writeOp == WriteOpDelete {
 			delete(refs, string(k))
-			continue
+		} else {
+			refs[string(k)] = FileRef{Offset: offset, Size: len(row)}
 		}
-		refs[string(k)] = FileRef{Offset: offset, Size: len(row)}
 		offset += len(row)
 	}
-	return offset, s.Err()
 }
@@ -178,3 +178,62 @@ func TestFormat(t *testing.T) {
 		}
 	})
 }
+
+func FuzzFormat(f *testing.F) {
+	f.Add(uint8(0), []byte("MyKey"), []byte(nil), byte('-'), byte('='), byte('!'), byte(' '), byte(' '), byte('\n'))
+	f.Add(uint8(1), []byte("MyKey"), []byte("MyValue"), byte('-'), byte('='), byte('!'), byte(' '), byte(' '), byte('\n'))
+	f.Add(uint8(1), []byte("My Key"), []byte(""), byte('-'), byte('='), byte('!'), byte(' '), byte(' '), byte('\n'))
+	f.Add(uint8(1), []byte(" key "), []byte("value\r"), byte('-'), byte('='), byte('!'), byte(' '), byte('\t'), byte('\n'))
+	f.Add(uint8(2), []byte("My\nKey"), []byte(nil), byte('p'), byte('k'), byte('d'), byte(':'), byte('='), byte(';'))
+
+	f.Fuzz(func(t *testing.T, op uint8, k, v []byte, putKey, putKeyValue, del, keyPrefix, valuePrefix, rowEnd byte) {
+		format := &Format{
+			PutKey:      putKey,
+			PutKeyValue: putKeyValue,
+			Delete:      del,
+			KeyPrefix:   keyPrefix,
+			ValuePrefix: valuePrefix,
+			RowEnd:      rowEnd,
+		}
+		if format.Validate() != nil {
+			return
+		}
+		writeOp := []WriteOp{WriteOpPutKey, WriteOpPutKeyValue, WriteOpDelete}[int(op)%3]
+		if writeOp != WriteOpPutKeyValue {
+			v = nil
+		}
+
+		row, err := format.Encode(writeOp, k, v)
+		if err != nil {
+			return // invalid rows must be rejected on encoding, not on decoding
+		}
+
+		// Decoding must yield the original data
+		gotWriteOp, gotK, gotV, err := format.ParseRowFromBytes(row)
+		if err != nil {
+			t.Fatalf("parse encoded row %q: %s", row, err)
+		}
+		if gotWriteOp != writeOp {
+			t.Fatalf("row %q: want write-op %q but got %q", row, writeOp, gotWriteOp)
+		}
+		if !bytes.Equal(gotK, k) {
+			t.Fatalf("row %q: want key %q but got %q", row, k, gotK)
+		}
+		if !bytes.Equal(gotV, v) {
+			t.Fatalf("row %q: want value %q but got %q", row, v, gotV)
+		}
+
+		// Reading the row from a file must yield the same key and size
+		refs := map[string]FileRef{}
+		offset, err := extractFileRefs(bytes.NewReader(row), format, refs)
+		if err != nil {
+			t.Fatalf("extract file refs from row %q: %s", row, err)
+		}
+		if offset != len(row) {
+			t.Fatalf("row %q: want offset %d but got %d", row, len(row), offset)
+		}
+		if ref, ok := refs[string(k)]; writeOp != WriteOpDelete && (!ok || ref.Size != len(row)) {
+			t.Fatalf("row %q: want file ref with size %d but got %+v", row, len(row), ref)
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package kv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HTTPHandler exposes a database over HTTP.
+//
+// It handles the following requests (relative to Prefix):
+//   - GET /keys/{key}: responds with the raw value
+//   - PUT /keys/{key}: sets the request body as value
+//   - DELETE /keys/{key}: removes the key
+//   - GET /keys?prefix={prefix}&after={key}&limit={n}: lists keys in lexicographical order
+//
+// It can be used with web.Routes, for ex: routes.Handle(h, web.MatchPathPrefix("/db/keys")).
+type HTTPHandler struct {
+	db           *DB
+	Prefix       string                     // Path prefix where the handler is mounted (for ex: "/db")
+	ReadOnly     bool                       // Rejects PUT and DELETE requests if true
+	Authorize    func(r *http.Request) bool // Optional, responds with 401 Unauthorized if false is returned
+	MaxValueSize int64                      // Default: 1MB
+	MaxPageSize  int                        // Default: 1000
+}
+
+// NewHTTPHandler instanciates a new HTTPHandler with default values.
+func NewHTTPHandler(db *DB, prefix string) *HTTPHandler {
+	return &HTTPHandler{db: db, Prefix: prefix, MaxValueSize: 1 << 20, MaxPageSize: 1000}
+}
+
+// KeyList is the JSON response body sent when listing keys.
+// Next is the cursor to use as "after" query parameter to get the next page (empty on last page).
+type KeyList struct {
+	Keys []string `json:"keys"`
+	Next string   `json:"next,omitempty"`
+}
+
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Authorize != nil && !h.Authorize(r) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, h.Prefix)
+	if path == "/keys" || path == "/keys/" {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			h.methodNotAllowed(w, http.MethodGet, http.MethodHead)
+			return
+		}
+		h.list(w, r)
+		return
+	}
+	if !strings.HasPrefix(path, "/keys/") {
+		http.NotFound(w, r)
+		return
+	}
+	k := []byte(strings.TrimPrefix(path, "/keys/"))
+
+	switch r.Method {
+	default:
+		if h.ReadOnly {
+			h.methodNotAllowed(w, http.MethodGet, http.MethodHead)
+		} else {
+			h.methodNotAllowed(w, http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete)
+		}
+	case http.MethodGet, http.MethodHead:
+		h.get(w, k)
+	case http.MethodPut:
+		if h.ReadOnly {
+			h.methodNotAllowed(w, http.MethodGet, http.MethodHead)
+			return
+		}
+		h.put(w, r, k)
+	case http.MethodDelete:
+		if h.ReadOnly {
+			h.methodNotAllowed(w, http.MethodGet, http.MethodHead)
+			return
+		}
+		h.delete(w, k)
+	}
+}
+
+func (h *HTTPHandler) methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+func (h *HTTPHandler) get(w http.ResponseWriter, k []byte) {
+	h.db.RLock()
+	v, err := h.db.Get(k)
+	h.db.RUnlock()
+	if errors.Is(err, ErrKeyNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(v)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(v)
+}
+
+func (h *HTTPHandler) put(w http.ResponseWriter, r *http.Request, k []byte) {
+	v, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.MaxValueSize))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, fmt.Sprintf("value exceeds max size of %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+		return
+	} else if err != nil {
+		http.Error(w, fmt.Sprintf("read body: %s", err), http.StatusBadRequest)
+		return
+	}
+	h.db.Lock()
+	err = h.db.Put(k, v)
+	h.db.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *HTTPHandler) delete(w http.ResponseWriter, k []byte) {
+	h.db.Lock()
+	defer h.db.Unlock()
+	if !h.db.KeyExists(k) {
+		http.Error(w, fmt.Sprintf("%s: %q", ErrKeyNotFound, k), http.StatusNotFound)
+		return
+	}
+	err := h.db.Delete(k)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *HTTPHandler) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prefix, after := query.Get("prefix"), query.Get("after")
+	limit := h.MaxPageSize
+	if rawLimit := query.Get("limit"); rawLimit != "" {
+		n, err := strconv.Atoi(rawLimit)
+		if err != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("invalid limit: %q", rawLimit), http.StatusBadRequest)
+			return
+		}
+		if n < limit || limit <= 0 {
+			limit = n
+		}
+	}
+
+	// Collect matching keys and sort them so pages are stable
+	keys := []string{}
+	h.db.RLock()
+	h.db.ForEachKey(func(k []byte) (stop bool) {
+		if strings.HasPrefix(string(k), prefix) && string(k) > after {
+			keys = append(keys, string(k))
+		}
+		return false
+	})
+	h.db.RUnlock()
+	sort.Strings(keys)
+
+	out := KeyList{Keys: keys}
+	if limit > 0 && len(keys) > limit {
+		out.Keys = keys[:limit]
+		out.Next = keys[limit-1]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(out)
+}
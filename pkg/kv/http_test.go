@@ -0,0 +1,99 @@
+package kv
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	newHandler := func(t *testing.T) *HTTPHandler {
+		db, err := NewDB(filepath.Join(t.TempDir(), "test.db"), DefaultFormat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return NewHTTPHandler(db, "/db")
+	}
+	do := func(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	t.Run("can put, get and delete a key", func(t *testing.T) {
+		h := newHandler(t)
+		if rec := do(h, http.MethodPut, "/db/keys/user/1", "alice"); rec.Code != http.StatusNoContent {
+			t.Fatalf("put: want status %d but got %d", http.StatusNoContent, rec.Code)
+		}
+		rec := do(h, http.MethodGet, "/db/keys/user/1", "")
+		if rec.Code != http.StatusOK || rec.Body.String() != "alice" {
+			t.Fatalf("get: want 200 %q but got %d %q", "alice", rec.Code, rec.Body.String())
+		}
+		if rec := do(h, http.MethodDelete, "/db/keys/user/1", ""); rec.Code != http.StatusNoContent {
+			t.Fatalf("delete: want status %d but got %d", http.StatusNoContent, rec.Code)
+		}
+		if rec := do(h, http.MethodGet, "/db/keys/user/1", ""); rec.Code != http.StatusNotFound {
+			t.Fatalf("get deleted: want status %d but got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("can list keys by prefix with pagination", func(t *testing.T) {
+		h := newHandler(t)
+		for _, k := range []string{"user/3", "user/1", "post/1", "user/2"} {
+			if err := h.db.Put([]byte(k), []byte("v")); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		wantPages := [][]string{{"user/1", "user/2"}, {"user/3"}}
+		after := ""
+		for i, want := range wantPages {
+			rec := do(h, http.MethodGet, "/db/keys?prefix=user/&limit=2&after="+after, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("page %d: want status 200 but got %d", i, rec.Code)
+			}
+			got := KeyList{}
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got.Keys, ",") != strings.Join(want, ",") {
+				t.Fatalf("page %d: want keys %q but got %q", i, want, got.Keys)
+			}
+			after = got.Next
+		}
+		if after != "" {
+			t.Fatalf("want empty cursor on last page but got %q", after)
+		}
+	})
+
+	t.Run("rejects writes in read-only mode", func(t *testing.T) {
+		h := newHandler(t)
+		h.ReadOnly = true
+		rec := do(h, http.MethodPut, "/db/keys/k", "v")
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("want status %d but got %d", http.StatusMethodNotAllowed, rec.Code)
+		}
+		if rec.Header().Get("Allow") != "GET, HEAD" {
+			t.Fatalf("want Allow header %q but got %q", "GET, HEAD", rec.Header().Get("Allow"))
+		}
+	})
+
+	t.Run("rejects unauthorized requests", func(t *testing.T) {
+		h := newHandler(t)
+		h.Authorize = func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer secret" }
+		if rec := do(h, http.MethodGet, "/db/keys", ""); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("want status %d but got %d", http.StatusUnauthorized, rec.Code)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/db/keys", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want status %d but got %d", http.StatusOK, rec.Code)
+		}
+	})
+}
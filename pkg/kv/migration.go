@@ -0,0 +1,144 @@
+package kv
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var ErrDBVersionTooNew = errors.New("database version is newer than latest known migration")
+
+// Default keys used by Migrator to record applied migrations in the database itself.
+var (
+	DefaultMigrationVersionKey   = []byte("_migration_version")
+	DefaultMigrationRecordPrefix = []byte("_migration/")
+)
+
+// MigrationFunc reads data from the database and buffers changes in the given batch.
+// The batch is written to the database (along with the migration version) once the function returns.
+type MigrationFunc func(db *DB, b *Batch) error
+
+// Migration represents a versioned change to the data stored in a database.
+type Migration struct {
+	Version int
+	Name    string
+	Up      MigrationFunc
+}
+
+// Migrator runs registered migrations in order.
+// The current database version is stored under VersionKey
+// and each applied migration is recorded under RecordPrefix + version.
+type Migrator struct {
+	db           *DB
+	migrations   []*Migration
+	VersionKey   []byte
+	RecordPrefix []byte
+}
+
+// NewMigrator instanciates a new Migrator with the default keys.
+func NewMigrator(db *DB) *Migrator {
+	return &Migrator{db: db, VersionKey: DefaultMigrationVersionKey, RecordPrefix: DefaultMigrationRecordPrefix}
+}
+
+// Register adds a migration to the list of known migrations.
+// Migrations can be registered in any order, they are sorted by version before running.
+func (m *Migrator) Register(version int, name string, up MigrationFunc) {
+	m.migrations = append(m.migrations, &Migration{Version: version, Name: name, Up: up})
+}
+
+// Version reports the version currently recorded in the database (0 if no migration was applied yet).
+func (m *Migrator) Version() (int, error) {
+	if !m.db.KeyExists(m.VersionKey) {
+		return 0, nil
+	}
+	v, err := m.db.Get(m.VersionKey)
+	if err != nil {
+		return 0, fmt.Errorf("get version: %w", err)
+	}
+	version, err := strconv.Atoi(string(v))
+	if err != nil {
+		return 0, fmt.Errorf("parse version %q: %w", v, err)
+	}
+	return version, nil
+}
+
+// LatestVersion reports the highest registered migration version.
+func (m *Migrator) LatestVersion() int {
+	latest := 0
+	for _, migration := range m.migrations {
+		if migration.Version > latest {
+			latest = migration.Version
+		}
+	}
+	return latest
+}
+
+// Migrate applies all pending migrations in order.
+// Each migration runs inside its own batch, the batch also records the new database version.
+// It fails with ErrDBVersionTooNew if the database version is newer than the latest registered migration,
+// this allows the app to refuse to start with code that doesn't know about the stored data.
+func (m *Migrator) Migrate() error {
+	migrations, err := m.sorted()
+	if err != nil {
+		return err
+	}
+
+	current, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if latest := m.LatestVersion(); current > latest {
+		return fmt.Errorf("%w: database is at version %d but latest migration is %d", ErrDBVersionTooNew, current, latest)
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			continue
+		}
+		b := m.db.NewBatch()
+		err := migration.Up(m.db, b)
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+		err = m.record(b, migration)
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): record: %w", migration.Version, migration.Name, err)
+		}
+		err = m.db.WriteBatch(b)
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): write batch: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return nil
+}
+
+// Returns registered migrations sorted by version, or an error if a version is invalid or duplicated.
+func (m *Migrator) sorted() ([]*Migration, error) {
+	out := make([]*Migration, len(m.migrations))
+	copy(out, m.migrations)
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	for i, migration := range out {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("migration %q has invalid version %d", migration.Name, migration.Version)
+		}
+		if migration.Up == nil {
+			return nil, fmt.Errorf("migration %d (%s) has no function", migration.Version, migration.Name)
+		}
+		if i > 0 && out[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migration.Version)
+		}
+	}
+	return out, nil
+}
+
+// Adds the version and migration record to the batch.
+func (m *Migrator) record(b *Batch, migration *Migration) error {
+	version := []byte(strconv.Itoa(migration.Version))
+	err := b.Put(append(append([]byte{}, m.RecordPrefix...), version...), []byte(time.Now().UTC().Format(time.RFC3339)))
+	if err != nil {
+		return err
+	}
+	return b.Put(m.VersionKey, version)
+}
@@ -0,0 +1,143 @@
+package kv
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrator(t *testing.T) {
+	t.Run("can apply pending migrations in order and record the version", func(t *testing.T) {
+		fpath := filepath.Join(t.TempDir(), "test.db")
+		db, err := NewDB(fpath, DefaultFormat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.Put([]byte("user1"), []byte("alice"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		calls := []int{}
+		m := NewMigrator(db)
+		m.Register(2, "rename user1", func(db *DB, b *Batch) error {
+			calls = append(calls, 2)
+			v, err := db.Get([]byte("user:1"))
+			if err != nil {
+				return err
+			}
+			return b.Put([]byte("user:1"), append(v, '!'))
+		})
+		m.Register(1, "prefix users", func(db *DB, b *Batch) error {
+			calls = append(calls, 1)
+			v, err := db.Get([]byte("user1"))
+			if err != nil {
+				return err
+			}
+			if err := b.Delete([]byte("user1")); err != nil {
+				return err
+			}
+			return b.Put([]byte("user:1"), v)
+		})
+		err = m.Migrate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(calls) != 2 || calls[0] != 1 || calls[1] != 2 {
+			t.Fatalf("want migrations called in order [1 2] but got %v", calls)
+		}
+
+		// Reopen database and check data and version
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+		db, err = NewDB(fpath, DefaultFormat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		if db.KeyExists([]byte("user1")) {
+			t.Fatal("want old key deleted")
+		}
+		v, err := db.Get([]byte("user:1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(v) != "alice!" {
+			t.Fatalf("want %q but got %q", "alice!", v)
+		}
+		m = NewMigrator(db)
+		version, err := m.Version()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != 2 {
+			t.Fatalf("want version 2 but got %d", version)
+		}
+
+		// Migrations that were already applied should not run again
+		m.Register(1, "prefix users", func(db *DB, b *Batch) error { t.Fatal("should not run"); return nil })
+		m.Register(2, "rename user1", func(db *DB, b *Batch) error { t.Fatal("should not run"); return nil })
+		if err := m.Migrate(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("should not write anything when a migration fails", func(t *testing.T) {
+		db, err := NewDB(filepath.Join(t.TempDir(), "test.db"), DefaultFormat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		wantErr := errors.New("oops")
+		m := NewMigrator(db)
+		m.Register(1, "failing", func(db *DB, b *Batch) error {
+			if err := b.Put([]byte("key"), []byte("value")); err != nil {
+				return err
+			}
+			return wantErr
+		})
+		err = m.Migrate()
+		if !errors.Is(err, wantErr) {
+			t.Fatalf("want error %q but got %v", wantErr, err)
+		}
+		if db.Count() != 0 {
+			t.Fatalf("want no keys but got %d", db.Count())
+		}
+	})
+
+	t.Run("should fail with ErrDBVersionTooNew if database is newer than code", func(t *testing.T) {
+		db, err := NewDB(filepath.Join(t.TempDir(), "test.db"), DefaultFormat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		err = db.Put(DefaultMigrationVersionKey, []byte("3"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := NewMigrator(db)
+		m.Register(1, "first", func(db *DB, b *Batch) error { return nil })
+		err = m.Migrate()
+		if !errors.Is(err, ErrDBVersionTooNew) {
+			t.Fatalf("want ErrDBVersionTooNew but got %v", err)
+		}
+	})
+
+	t.Run("should fail on duplicate versions", func(t *testing.T) {
+		db, err := NewDB(filepath.Join(t.TempDir(), "test.db"), DefaultFormat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		m := NewMigrator(db)
+		m.Register(1, "first", func(db *DB, b *Batch) error { return nil })
+		m.Register(1, "second", func(db *DB, b *Batch) error { return nil })
+		if err := m.Migrate(); err == nil {
+			t.Fatal("want error but got nil")
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package logs
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -29,13 +30,29 @@ func NewTextLogger(writers ...io.Writer) *TextLogger {
 	return &TextLogger{writers: writers, mutex: &sync.Mutex{}}
 }
 
-func (l *TextLogger) Log(s string) {
+func (l *TextLogger) Log(s string) { l.write(getStackLevel(2), "", s) }
+
+// LogContext is like Log but includes the request ID found in the context (if any):
+//
+// "%s (%s) [%s] %q\n" with timestamp, source code location, request ID and log message between quotes.
+func (l *TextLogger) LogContext(ctx context.Context, s string) { l.logContext(1, ctx, s) }
+
+// Logs with the source code location found by skipping the given number of callers
+// (1 reports the caller of the function calling logContext).
+func (l *TextLogger) logContext(skip int, ctx context.Context, s string) {
+	l.write(getStackLevel(2+skip), RequestIDFromContext(ctx), s)
+}
+
+func (l *TextLogger) write(location, requestID, s string) {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
 	for _, w := range l.writers {
 		timestr := time.Now().Format("2006-01-02 15:04:05.000 Z07:00")
-		logstr := fmt.Sprintf("%s (%s) %q\n", timestr, getStackLevel(2), s)
+		logstr := fmt.Sprintf("%s (%s) %q\n", timestr, location, s)
+		if requestID != "" {
+			logstr = fmt.Sprintf("%s (%s) [%s] %q\n", timestr, location, requestID, s)
+		}
 		_, err := w.Write([]byte(logstr))
 		if err != nil {
 			panic(err)
@@ -43,6 +60,38 @@ func (l *TextLogger) Log(s string) {
 	}
 }
 
+// ContextLogger is implemented by loggers that can include request-scoped data (like the request ID).
+type ContextLogger interface {
+	LogContext(ctx context.Context, s string)
+}
+
+// LogContext logs using LogContext if the logger implements ContextLogger, and Log otherwise.
+func LogContext(l Logger, ctx context.Context, s string) {
+	switch l := l.(type) {
+	case *TextLogger:
+		l.logContext(1, ctx, s) // report the location of the caller of this function
+	case ContextLogger:
+		l.LogContext(ctx, s)
+	default:
+		l.Log(s)
+	}
+}
+
+type ctxKey int
+
+const ctxKeyRequestID ctxKey = iota
+
+// WithRequestID returns a copy of the context holding the given request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKeyRequestID, id)
+}
+
+// RequestIDFromContext returns the request ID stored in the context (or an empty string).
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKeyRequestID).(string)
+	return id
+}
+
 // Opens a log file with the appropriate flag and mode.
 func MustOpenLogFile(path string) *os.File {
 	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0200)
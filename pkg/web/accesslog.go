@@ -0,0 +1,157 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ejuju/go-utils/pkg/logs"
+)
+
+// AccessLogFormat defines how access log lines are formatted.
+type AccessLogFormat int
+
+const (
+	AccessLogFormatText     AccessLogFormat = iota // "{status} {method} {duration}μs {path}" followed by key=value fields
+	AccessLogFormatJSON                            // JSON object with status, method, duration_us, path and fields
+	AccessLogFormatCommon                          // Common Log Format (fields are ignored)
+	AccessLogFormatCombined                        // Combined Log Format (fields are ignored)
+)
+
+// AccessLogField represents an optional access log field.
+type AccessLogField string
+
+const (
+	AccessLogFieldClientIP     AccessLogField = "client_ip"
+	AccessLogFieldUserAgent    AccessLogField = "user_agent"
+	AccessLogFieldReferrer     AccessLogField = "referrer"
+	AccessLogFieldRequestID    AccessLogField = "request_id"
+	AccessLogFieldResponseSize AccessLogField = "response_size"
+	AccessLogFieldQuery        AccessLogField = "query"
+)
+
+// AccessLogConfig configures AccessLoggingMiddlewareWithConfig.
+type AccessLogConfig struct {
+	Logger           logs.Logger
+	Format           AccessLogFormat
+	Fields           []AccessLogField  // Optional fields added to text and JSON logs (in order), the request ID is added if available
+	UseXForwardedFor bool              // Use X-Forwarded-For header to get the client IP address (trusting any proxy, prefer ClientIP)
+	ClientIP         *ClientIPResolver // Optional, resolves the client IP address (takes precedence over UseXForwardedFor)
+}
+
+// Access logging middleware logs incoming HTTP requests
+func AccessLoggingMiddleware(logger logs.Logger) func(http.Handler) http.Handler {
+	return AccessLoggingMiddlewareWithConfig(&AccessLogConfig{Logger: logger})
+}
+
+// Like AccessLoggingMiddleware but with a configurable format and fields.
+func AccessLoggingMiddlewareWithConfig(config *AccessLogConfig) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			resrec := NewResponseStatusRecorder(w) // use custom response writer to record status and size
+			before := time.Now()                   // record timestamp before request is handled
+			h.ServeHTTP(resrec, r)                 //
+			dur := time.Since(before)              // calculate duration to handle request
+
+			config.Logger.Log(formatAccessLog(config, resrec, r, before, dur))
+		})
+	}
+}
+
+func formatAccessLog(config *AccessLogConfig, resrec *ResponseStatusRecorder, r *http.Request, at time.Time, dur time.Duration) string {
+	switch config.Format {
+	case AccessLogFormatCommon, AccessLogFormatCombined:
+		user := "-"
+		if username, _, ok := r.BasicAuth(); ok && username != "" {
+			user = username
+		}
+		size := "-"
+		if resrec.BytesWritten > 0 {
+			size = strconv.Itoa(resrec.BytesWritten)
+		}
+		out := fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s",
+			accessLogFieldValue(config, AccessLogFieldClientIP, resrec, r),
+			user,
+			at.Format("02/Jan/2006:15:04:05 -0700"),
+			r.Method, r.URL.RequestURI(), r.Proto,
+			resrec.StatusCode,
+			size,
+		)
+		if config.Format == AccessLogFormatCombined {
+			out += fmt.Sprintf(" %q %q", r.Referer(), r.UserAgent())
+		}
+		return out
+	case AccessLogFormatJSON:
+		fields := map[string]any{
+			"status":      resrec.StatusCode,
+			"method":      r.Method,
+			"duration_us": dur.Microseconds(),
+			"path":        r.URL.Path,
+		}
+		for _, field := range accessLogFields(config, resrec, r) {
+			if field == AccessLogFieldResponseSize {
+				fields[string(field)] = resrec.BytesWritten
+				continue
+			}
+			fields[string(field)] = accessLogFieldValue(config, field, resrec, r)
+		}
+		raw, err := json.Marshal(fields)
+		if err != nil {
+			panic(err)
+		}
+		return string(raw)
+	default:
+		out := fmt.Sprintf("%d %-4s %5dμs %s", resrec.StatusCode, r.Method, dur.Microseconds(), r.URL.Path)
+		for _, field := range accessLogFields(config, resrec, r) {
+			out += fmt.Sprintf(" %s=%q", field, accessLogFieldValue(config, field, resrec, r))
+		}
+		return out
+	}
+}
+
+// Returns the configured fields, with the request ID field appended if a request ID is available.
+func accessLogFields(config *AccessLogConfig, resrec *ResponseStatusRecorder, r *http.Request) []AccessLogField {
+	for _, field := range config.Fields {
+		if field == AccessLogFieldRequestID {
+			return config.Fields
+		}
+	}
+	if accessLogFieldValue(config, AccessLogFieldRequestID, resrec, r) == "" {
+		return config.Fields
+	}
+	return append(config.Fields[:len(config.Fields):len(config.Fields)], AccessLogFieldRequestID)
+}
+
+func accessLogFieldValue(config *AccessLogConfig, field AccessLogField, resrec *ResponseStatusRecorder, r *http.Request) string {
+	switch field {
+	default:
+		return ""
+	case AccessLogFieldClientIP:
+		res := config.ClientIP
+		if res == nil && config.UseXForwardedFor {
+			res = trustAllXForwardedFor
+		} else if res == nil {
+			res = DefaultClientIPResolver
+		}
+		ip, err := res.ClientIP(r)
+		if err != nil {
+			return "-"
+		}
+		return ip.String()
+	case AccessLogFieldUserAgent:
+		return r.UserAgent()
+	case AccessLogFieldReferrer:
+		return r.Referer()
+	case AccessLogFieldRequestID:
+		if id := RequestID(r); id != "" {
+			return id
+		}
+		return resrec.Header().Get(RequestIDHeader) // set by RequestIDMiddleware when used after this middleware
+	case AccessLogFieldResponseSize:
+		return strconv.Itoa(resrec.BytesWritten)
+	case AccessLogFieldQuery:
+		return r.URL.RawQuery
+	}
+}
@@ -0,0 +1,70 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+type mockLogger []string
+
+func (l *mockLogger) Log(s string) { *l = append(*l, s) }
+
+func TestAccessLoggingMiddleware(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("hello")) })
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/path?q=1", nil)
+		req.Header.Set("User-Agent", "test-agent")
+		req.Header.Set("Referer", "https://example.com")
+		req.Header.Set("X-Forwarded-For", "203.0.113.1")
+		return req
+	}
+
+	tests := []struct {
+		description string
+		config      *AccessLogConfig
+		want        *regexp.Regexp
+	}{
+		{
+			description: "can log text with fields",
+			config:      &AccessLogConfig{Fields: []AccessLogField{AccessLogFieldClientIP, AccessLogFieldResponseSize, AccessLogFieldQuery}},
+			want:        regexp.MustCompile(`^200 GET  +\d+μs /path client_ip="192.0.2.1" response_size="5" query="q=1"$`),
+		},
+		{
+			description: "can log client IP forwarded by trusted proxy",
+			config:      &AccessLogConfig{Fields: []AccessLogField{AccessLogFieldClientIP}, ClientIP: MustNewClientIPResolver("192.0.2.1")},
+			want:        regexp.MustCompile(`^200 GET  +\d+μs /path client_ip="203.0.113.1"$`),
+		},
+		{
+			description: "can log in combined log format",
+			config:      &AccessLogConfig{Format: AccessLogFormatCombined},
+			want:        regexp.MustCompile(`^192\.0\.2\.1 - - \[.+\] "GET /path\?q=1 HTTP/1\.1" 200 5 "https://example.com" "test-agent"$`),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			logger := &mockLogger{}
+			test.config.Logger = logger
+			Wrap(handler, AccessLoggingMiddlewareWithConfig(test.config)).ServeHTTP(httptest.NewRecorder(), newRequest())
+			if len(*logger) != 1 || !test.want.MatchString((*logger)[0]) {
+				t.Fatalf("want log matching %q but got %q", test.want, *logger)
+			}
+		})
+	}
+
+	t.Run("can log JSON with fields", func(t *testing.T) {
+		logger := &mockLogger{}
+		config := &AccessLogConfig{Logger: logger, Format: AccessLogFormatJSON, Fields: []AccessLogField{AccessLogFieldUserAgent, AccessLogFieldResponseSize}}
+		Wrap(handler, AccessLoggingMiddlewareWithConfig(config)).ServeHTTP(httptest.NewRecorder(), newRequest())
+
+		got := map[string]any{}
+		if err := json.Unmarshal([]byte((*logger)[0]), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got["status"] != 200.0 || got["path"] != "/path" || got["user_agent"] != "test-agent" || got["response_size"] != 5.0 {
+			t.Fatalf("unexpected JSON log: %v", got)
+		}
+	})
+}
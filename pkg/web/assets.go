@@ -0,0 +1,137 @@
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Assets serves static files from a fs.FS (for ex: embed.FS) at fingerprinted paths
+// (for ex: "css/main.css" is served at "/static/css/main.3f2a9c1b04e7d8a6.css").
+// Fingerprinted paths change when the content changes, so they are cached forever (see CacheImmutable).
+// Use URL to get the path of an asset when generating HTML, for ex:
+//
+//	wui.NewStylesheet(assets.URL("css/main.css"))
+//
+// All files are read in memory when calling NewAssets.
+type Assets struct {
+	prefix  string
+	byName  map[string]*asset // by original name (for ex: "css/main.css")
+	byPath  map[string]*asset // by fingerprinted name (for ex: "css/main.3f2a9c1b04e7d8a6.css")
+	handler http.Handler
+}
+
+type asset struct {
+	name        string
+	path        string // fingerprinted name
+	content     []byte
+	contentType string
+	etag        string
+}
+
+// NewAssets reads all files of the file system and computes their fingerprinted paths.
+// The prefix is the path where assets are served (for ex: "/static/").
+func NewAssets(fsys fs.FS, prefix string) (*Assets, error) {
+	a := &Assets{
+		prefix: "/" + strings.Trim(prefix, "/") + "/",
+		byName: map[string]*asset{},
+		byPath: map[string]*asset{},
+	}
+	if a.prefix == "//" {
+		a.prefix = "/"
+	}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		hash := sha256.Sum256(content)
+		ext := path.Ext(name)
+		item := &asset{
+			name:        name,
+			path:        strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(hash[:8]) + ext,
+			content:     content,
+			contentType: mime.TypeByExtension(ext),
+			etag:        StrongETag(content),
+		}
+		if item.contentType == "" {
+			item.contentType = http.DetectContentType(content)
+		}
+		a.byName[item.name] = item
+		a.byPath[item.path] = item
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read assets: %w", err)
+	}
+	a.handler = http.StripPrefix(a.prefix, http.HandlerFunc(a.serve))
+	return a, nil
+}
+
+// MustNewAssets is like NewAssets but panics on error.
+func MustNewAssets(fsys fs.FS, prefix string) *Assets {
+	a, err := NewAssets(fsys, prefix)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// Lookup returns the fingerprinted URL path of the asset with the given name (for ex: "css/main.css").
+func (a *Assets) Lookup(name string) (string, bool) {
+	item, ok := a.byName[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return "", false
+	}
+	return a.prefix + item.path, true
+}
+
+// URL is like Lookup but returns the non-fingerprinted URL path if the asset doesn't exist.
+func (a *Assets) URL(name string) string {
+	if url, ok := a.Lookup(name); ok {
+		return url
+	}
+	return a.prefix + strings.TrimPrefix(name, "/")
+}
+
+// Names returns the names of all assets (sorted).
+func (a *Assets) Names() []string {
+	names := make([]string, 0, len(a.byName))
+	for name := range a.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServeHTTP serves assets requested at their fingerprinted path with CacheImmutable.
+// Assets requested at their original path are served with CacheRevalidate.
+func (a *Assets) ServeHTTP(w http.ResponseWriter, r *http.Request) { a.handler.ServeHTTP(w, r) }
+
+func (a *Assets) serve(w http.ResponseWriter, r *http.Request) {
+	policy := CacheImmutable
+	item, ok := a.byPath[r.URL.Path]
+	if !ok {
+		policy = CacheRevalidate
+		item, ok = a.byName[r.URL.Path]
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", policy.String())
+	w.Header().Set("Content-Type", item.contentType)
+	w.Header().Set("ETag", item.etag)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(item.content))
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAssets(t *testing.T) {
+	fsys := fstest.MapFS{
+		"css/main.css": {Data: []byte("body { color: black; }")},
+		"js/app.js":    {Data: []byte("console.log('hello')")},
+		"robots":       {Data: []byte("hello")},
+	}
+	assets, err := NewAssets(fsys, "static")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, ok := assets.Lookup("css/main.css")
+	if !ok || !strings.HasPrefix(url, "/static/css/main.") || !strings.HasSuffix(url, ".css") || url == "/static/css/main.css" {
+		t.Fatalf("want fingerprinted URL but got %q", url)
+	}
+	if got := assets.URL("/css/main.css"); got != url {
+		t.Fatalf("want %q but got %q", url, got)
+	}
+	if got := assets.URL("missing.css"); got != "/static/missing.css" {
+		t.Fatalf("want non-fingerprinted URL but got %q", got)
+	}
+	if got := strings.Join(assets.Names(), ","); got != "css/main.css,js/app.js,robots" {
+		t.Fatalf("want sorted names but got %q", got)
+	}
+
+	// Changing the content should change the URL
+	other, err := NewAssets(fstest.MapFS{"css/main.css": {Data: []byte("body { color: white; }")}}, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := other.URL("css/main.css"); got == url {
+		t.Fatalf("want different URL for different content but got %q", got)
+	}
+
+	tests := []struct {
+		path             string
+		wantStatus       int
+		wantCacheControl string
+		wantContentType  string
+	}{
+		{path: url, wantStatus: http.StatusOK, wantCacheControl: CacheImmutable.String(), wantContentType: "text/css; charset=utf-8"},
+		{path: assets.URL("js/app.js"), wantStatus: http.StatusOK, wantCacheControl: CacheImmutable.String()},
+		{path: "/static/css/main.css", wantStatus: http.StatusOK, wantCacheControl: CacheRevalidate.String(), wantContentType: "text/css; charset=utf-8"},
+		{path: assets.URL("robots"), wantStatus: http.StatusOK, wantCacheControl: CacheImmutable.String(), wantContentType: "text/plain; charset=utf-8"},
+		{path: "/static/css/main.0000000000000000.css", wantStatus: http.StatusNotFound},
+		{path: "/other/css/main.css", wantStatus: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		resrec := httptest.NewRecorder()
+		assets.ServeHTTP(resrec, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if resrec.Code != test.wantStatus {
+			t.Fatalf("%q: want status %d but got %d", test.path, test.wantStatus, resrec.Code)
+		}
+		if test.wantStatus != http.StatusOK {
+			continue
+		}
+		if got := resrec.Header().Get("Cache-Control"); got != test.wantCacheControl {
+			t.Fatalf("%q: want Cache-Control %q but got %q", test.path, test.wantCacheControl, got)
+		}
+		if got := resrec.Header().Get("Content-Type"); test.wantContentType != "" && got != test.wantContentType {
+			t.Fatalf("%q: want Content-Type %q but got %q", test.path, test.wantContentType, got)
+		}
+	}
+
+	// Conditional requests
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("If-None-Match", StrongETag(fsys["css/main.css"].Data))
+	resrec := httptest.NewRecorder()
+	assets.ServeHTTP(resrec, req)
+	if resrec.Code != http.StatusNotModified {
+		t.Fatalf("want status %d but got %d", http.StatusNotModified, resrec.Code)
+	}
+}
@@ -0,0 +1,207 @@
+package web
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CachePolicy describes the Cache-Control header of a response.
+// The zero value doesn't set any header.
+type CachePolicy struct {
+	MaxAge    time.Duration // Time during which the response is fresh (sent as max-age if positive)
+	Immutable bool          // The response never changes (use with fingerprinted URLs)
+	Private   bool          // Only the browser may cache the response (not shared caches)
+	NoCache   bool          // Caches must revalidate the response before using it
+	NoStore   bool          // The response must not be stored at all
+}
+
+var (
+	CacheImmutable  = CachePolicy{MaxAge: 365 * 24 * time.Hour, Immutable: true}
+	CacheRevalidate = CachePolicy{NoCache: true}
+	CacheNoStore    = CachePolicy{NoStore: true}
+)
+
+// String returns the Cache-Control header value.
+func (p CachePolicy) String() string {
+	directives := []string{}
+	if p.Private {
+		directives = append(directives, "private")
+	} else if p.MaxAge > 0 || p.Immutable {
+		directives = append(directives, "public")
+	}
+	if p.NoStore {
+		directives = append(directives, "no-store")
+	}
+	if p.NoCache {
+		directives = append(directives, "no-cache")
+	}
+	if p.MaxAge > 0 {
+		directives = append(directives, "max-age="+strconv.Itoa(int(p.MaxAge.Seconds())))
+	}
+	if p.Immutable {
+		directives = append(directives, "immutable")
+	}
+	return strings.Join(directives, ", ")
+}
+
+// StrongETag returns a strong entity tag computed from the SHA-256 hash of the content.
+func StrongETag(v []byte) string {
+	hash := sha256.Sum256(v)
+	return `"` + base64.RawURLEncoding.EncodeToString(hash[:16]) + `"`
+}
+
+// Reports whether the If-None-Match header value matches the entity tag (using weak comparison).
+func etagMatches(ifNoneMatch, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether the response to a GET or HEAD request is not modified (based on the request conditional headers).
+// If-None-Match takes precedence over If-Modified-Since.
+func isNotModified(r *http.Request, etag, lastModified string) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+	ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modTime, err := http.ParseTime(lastModified)
+	return err == nil && !modTime.After(ifModifiedSince)
+}
+
+// Sends a 304 Not Modified response (without representation headers).
+func writeNotModified(w http.ResponseWriter) {
+	header := w.Header()
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// Cache middleware sets the Cache-Control header of successful (2xx) and 304 responses according to the policy
+// (unless the handler sets it) and handles conditional GET and HEAD requests:
+// successful responses get a strong ETag (unless the handler sets one)
+// and 304 Not Modified is sent when the If-None-Match or If-Modified-Since header matches.
+//
+// Responses are buffered to compute the ETag, unless the handler flushes them (for ex: for streaming).
+// Apply it per route to use different policies, for ex:
+//
+//	routes.Handle(web.Wrap(assets, web.CacheMiddleware(web.CacheImmutable)), web.MatchPathPrefix("/static/"))
+func CacheMiddleware(policy CachePolicy) func(http.Handler) http.Handler {
+	cacheControl := policy.String()
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Set Cache-Control once the status is known (so errors are not cached)
+			srec := NewResponseStatusRecorder(w)
+			srec.BeforeHeader = func() {
+				cacheable := srec.StatusCode/100 == 2 || srec.StatusCode == http.StatusNotModified
+				if cacheControl != "" && cacheable && srec.Header().Get("Cache-Control") == "" {
+					srec.Header().Set("Cache-Control", cacheControl)
+				}
+			}
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				h.ServeHTTP(srec, r)
+			} else {
+				ew := &etagWriter{ResponseWriter: srec, statusCode: http.StatusOK}
+				h.ServeHTTP(ew, r)
+				ew.finish(r)
+			}
+			if !srec.WroteHeader() {
+				srec.BeforeHeader() // the handler didn't write anything, a 200 status is sent
+			}
+		})
+	}
+}
+
+// Buffers the response to compute its ETag.
+type etagWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	buf         []byte
+	passthrough bool // set once the response is flushed
+}
+
+func (ew *etagWriter) WriteHeader(statusCode int) {
+	if ew.passthrough || (statusCode >= 100 && statusCode < 200) {
+		ew.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if !ew.wroteHeader {
+		ew.statusCode = statusCode
+		ew.wroteHeader = true
+	}
+}
+
+func (ew *etagWriter) Write(b []byte) (int, error) {
+	if ew.passthrough {
+		return ew.ResponseWriter.Write(b)
+	}
+	ew.wroteHeader = true
+	ew.buf = append(ew.buf, b...)
+	return len(b), nil
+}
+
+// Flush sends the buffered response without ETag and stops buffering.
+func (ew *etagWriter) Flush() {
+	if !ew.passthrough {
+		ew.passthrough = true
+		ew.ResponseWriter.WriteHeader(ew.statusCode)
+		ew.ResponseWriter.Write(ew.buf)
+		ew.buf = nil
+	}
+	http.NewResponseController(ew.ResponseWriter).Flush()
+}
+
+// Hijack sends the buffered response (if any) and takes over the connection.
+func (ew *etagWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if !ew.passthrough {
+		ew.passthrough = true
+		if ew.wroteHeader {
+			ew.ResponseWriter.WriteHeader(ew.statusCode)
+			ew.ResponseWriter.Write(ew.buf)
+			ew.buf = nil
+		}
+	}
+	return http.NewResponseController(ew.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying response writer (used by http.ResponseController).
+func (ew *etagWriter) Unwrap() http.ResponseWriter { return ew.ResponseWriter }
+
+// Writes the buffered response or 304 Not Modified.
+func (ew *etagWriter) finish(r *http.Request) {
+	if ew.passthrough {
+		return
+	}
+	header := ew.Header()
+	if header.Get("Content-Type") == "" && len(ew.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(ew.buf))
+	}
+	if ew.statusCode == http.StatusOK {
+		if header.Get("ETag") == "" {
+			header.Set("ETag", StrongETag(ew.buf))
+		}
+		if isNotModified(r, header.Get("ETag"), header.Get("Last-Modified")) {
+			writeNotModified(ew.ResponseWriter)
+			return
+		}
+	}
+	ew.ResponseWriter.WriteHeader(ew.statusCode)
+	ew.ResponseWriter.Write(ew.buf)
+}
@@ -0,0 +1,157 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachePolicy(t *testing.T) {
+	tests := []struct {
+		policy CachePolicy
+		want   string
+	}{
+		{policy: CachePolicy{}, want: ""},
+		{policy: CacheImmutable, want: "public, max-age=31536000, immutable"},
+		{policy: CacheRevalidate, want: "no-cache"},
+		{policy: CacheNoStore, want: "no-store"},
+		{policy: CachePolicy{MaxAge: time.Minute, Private: true}, want: "private, max-age=60"},
+	}
+	for _, test := range tests {
+		if got := test.policy.String(); got != test.want {
+			t.Fatalf("want %q but got %q", test.want, got)
+		}
+	}
+}
+
+func TestServeRaw(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "index.html")
+	content := []byte("<!DOCTYPE html><html>hello world</html>")
+	if err := os.WriteFile(fpath, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(fpath, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	handlers := map[string]http.Handler{
+		"ServeRaw":         ServeRaw(content),
+		"ReadAndServeFile": ReadAndServeFile(fpath),
+		"CacheMiddleware": Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(content)
+		}), CacheMiddleware(CacheImmutable)),
+	}
+	for name, h := range handlers {
+		t.Run(name, func(t *testing.T) {
+			do := func(header http.Header) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header = header
+				resrec := httptest.NewRecorder()
+				h.ServeHTTP(resrec, req)
+				return resrec
+			}
+
+			resrec := do(http.Header{})
+			etag := resrec.Header().Get("ETag")
+			if resrec.Code != http.StatusOK || resrec.Body.String() != string(content) || etag != StrongETag(content) {
+				t.Fatalf("want 200 with content and ETag but got %d %q %q", resrec.Code, resrec.Body.String(), etag)
+			}
+			if got := resrec.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+				t.Fatalf("want HTML content type but got %q", got)
+			}
+
+			type conditionalTest struct {
+				description string
+				header      http.Header
+				want        int
+			}
+			tests := []conditionalTest{
+				{description: "matching ETag", header: http.Header{"If-None-Match": {`"x", ` + etag}}, want: http.StatusNotModified},
+				{description: "matching weak ETag", header: http.Header{"If-None-Match": {"W/" + etag}}, want: http.StatusNotModified},
+				{description: "other ETag", header: http.Header{"If-None-Match": {`"x"`}}, want: http.StatusOK},
+			}
+			if name == "ReadAndServeFile" {
+				tests = append(tests,
+					conditionalTest{description: "not modified since", header: http.Header{"If-Modified-Since": {modTime.Format(http.TimeFormat)}}, want: http.StatusNotModified},
+					conditionalTest{description: "modified since", header: http.Header{"If-Modified-Since": {modTime.Add(-time.Hour).Format(http.TimeFormat)}}, want: http.StatusOK},
+				)
+			}
+			for _, test := range tests {
+				resrec := do(test.header)
+				if resrec.Code != test.want {
+					t.Fatalf("%s: want status %d but got %d", test.description, test.want, resrec.Code)
+				}
+				if test.want == http.StatusNotModified && resrec.Body.Len() != 0 {
+					t.Fatalf("%s: want empty body but got %q", test.description, resrec.Body.String())
+				}
+			}
+
+			if name == "CacheMiddleware" {
+				if got := resrec.Header().Get("Cache-Control"); got != CacheImmutable.String() {
+					t.Fatalf("want Cache-Control %q but got %q", CacheImmutable.String(), got)
+				}
+				return
+			}
+
+			// Range requests
+			resrec = do(http.Header{"Range": {"bytes=21-25"}})
+			if resrec.Code != http.StatusPartialContent || resrec.Body.String() != "hello" {
+				t.Fatalf("want partial content %q but got %d %q", "hello", resrec.Code, resrec.Body.String())
+			}
+			resrec = do(http.Header{"Range": {"bytes=21-25"}, "If-Range": {`"x"`}})
+			if resrec.Code != http.StatusOK {
+				t.Fatalf("want full content when If-Range doesn't match but got %d", resrec.Code)
+			}
+		})
+	}
+
+	t.Run("can stream responses through CacheMiddleware", func(t *testing.T) {
+		h := CacheMiddleware(CachePolicy{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "first")
+			http.NewResponseController(w).Flush()
+			io.WriteString(w, "second")
+		}))
+		resrec := httptest.NewRecorder()
+		h.ServeHTTP(resrec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if !resrec.Flushed || resrec.Body.String() != "firstsecond" || resrec.Header().Get("ETag") != "" {
+			t.Fatalf("want flushed body without ETag but got %q %q", resrec.Body.String(), resrec.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("should only set Cache-Control on successful and not modified responses", func(t *testing.T) {
+		tests := []struct {
+			method           string
+			status           int
+			handlerSetHeader bool
+			want             string
+		}{
+			{method: http.MethodGet, status: http.StatusOK, want: CacheImmutable.String()},
+			{method: http.MethodGet, status: http.StatusNotFound},
+			{method: http.MethodGet, status: http.StatusInternalServerError},
+			{method: http.MethodGet, status: http.StatusOK, handlerSetHeader: true, want: "no-cache"},
+			{method: http.MethodPost, status: http.StatusCreated, want: CacheImmutable.String()},
+			{method: http.MethodPost, status: http.StatusBadRequest},
+			{method: http.MethodPost, status: 0, want: CacheImmutable.String()}, // nothing written
+		}
+		for _, test := range tests {
+			h := CacheMiddleware(CacheImmutable)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.handlerSetHeader {
+					w.Header().Set("Cache-Control", "no-cache")
+				}
+				if test.status != 0 {
+					w.WriteHeader(test.status)
+				}
+			}))
+			resrec := httptest.NewRecorder()
+			h.ServeHTTP(resrec, httptest.NewRequest(test.method, "/", nil))
+			if got := resrec.Header().Get("Cache-Control"); got != test.want {
+				t.Fatalf("%s %d: want Cache-Control %q but got %q", test.method, test.status, test.want, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var ErrInvalidClientIP = errors.New("invalid client IP address")
+
+// Headers used to forward the client IP address by proxies.
+const (
+	HeaderForwarded     = "Forwarded"
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-IP"
+)
+
+// ClientIPResolver gets the IP address of the client that sent a request.
+//
+// The forwarding header is only used when the request comes from a trusted proxy,
+// and only one header is used: set Header to the header your proxy sets (or appends to).
+// Other forwarding headers are ignored since proxies usually pass them through unchanged.
+//
+// IP address chains (in X-Forwarded-For and Forwarded) are read from right to left
+// and the first address that isn't a trusted proxy is returned,
+// so entries added by clients before the proxies' entries are ignored.
+// Reading stops at the first malformed entry (for ex: "unknown" or an obfuscated identifier in Forwarded),
+// the address of the last trusted proxy (or the remote address) is then returned.
+type ClientIPResolver struct {
+	TrustedProxies []*net.IPNet // Proxies allowed to set the forwarding header
+	Header         string       // Forwarding header set by the trusted proxies (default: X-Forwarded-For)
+}
+
+// DefaultClientIPResolver trusts no proxy and uses the remote address of the connection.
+var DefaultClientIPResolver = &ClientIPResolver{}
+
+// NewClientIPResolver returns a resolver trusting proxies in the given CIDR ranges or IP addresses
+// (for ex: "10.0.0.0/8" or "127.0.0.1").
+func NewClientIPResolver(trustedProxies ...string) (*ClientIPResolver, error) {
+	res := &ClientIPResolver{}
+	for _, s := range trustedProxies {
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+		_, ipnet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("parse trusted proxy %q: %w", s, err)
+		}
+		res.TrustedProxies = append(res.TrustedProxies, ipnet)
+	}
+	return res, nil
+}
+
+// MustNewClientIPResolver is like NewClientIPResolver but panics on error.
+func MustNewClientIPResolver(trustedProxies ...string) *ClientIPResolver {
+	res, err := NewClientIPResolver(trustedProxies...)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// ClientIP returns the IP address of the client.
+func (res *ClientIPResolver) ClientIP(r *http.Request) (net.IP, error) {
+	remote, err := parseIPWithOptionalPort(r.RemoteAddr)
+	if err != nil {
+		return nil, fmt.Errorf("remote address: %w", err)
+	}
+	if !res.isTrusted(remote) {
+		return remote, nil
+	}
+
+	k := res.Header
+	if k == "" {
+		k = HeaderXForwardedFor
+	}
+	values := r.Header.Values(k)
+	if len(values) == 0 {
+		return remote, nil
+	}
+	var chain []string
+	switch http.CanonicalHeaderKey(k) {
+	default:
+		chain = splitHeaderList(values)
+	case HeaderForwarded:
+		chain = forwardedForChain(values)
+	case http.CanonicalHeaderKey(HeaderXRealIP):
+		chain = values[len(values)-1:]
+	}
+
+	// Return the rightmost address that isn't a trusted proxy (or the leftmost one if all are trusted),
+	// stopping at the first malformed entry (entries on its left can't be trusted)
+	last := remote
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip, err := parseIPWithOptionalPort(chain[i])
+		if err != nil {
+			return last, nil
+		}
+		if !res.isTrusted(ip) {
+			return ip, nil
+		}
+		last = ip
+	}
+	return last, nil
+}
+
+func (res *ClientIPResolver) isTrusted(ip net.IP) bool {
+	for _, ipnet := range res.TrustedProxies {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Splits comma-separated header values.
+func splitHeaderList(values []string) []string {
+	out := []string{}
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+// Returns the "for" parameters of the Forwarded header (RFC 7239), for ex:
+// `for=192.0.2.60;proto=http, for="[2001:db8::1]:4711"`.
+func forwardedForChain(values []string) []string {
+	out := []string{}
+	for _, element := range splitHeaderList(values) {
+		for _, pair := range strings.Split(element, ";") {
+			k, v, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if found && strings.EqualFold(k, "for") {
+				out = append(out, strings.Trim(v, `"`))
+			}
+		}
+	}
+	return out
+}
+
+// Parses an IP address with an optional port (for ex: "192.0.2.1", "192.0.2.1:80", "[2001:db8::1]:80" or "2001:db8::1").
+func parseIPWithOptionalPort(s string) (net.IP, error) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	ip := net.ParseIP(strings.Trim(s, "[]"))
+	if ip == nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidClientIP, s)
+	}
+	return ip, nil
+}
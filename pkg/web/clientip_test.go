@@ -0,0 +1,116 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	res := MustNewClientIPResolver("10.0.0.0/8", "2001:db8::1")
+	tests := []struct {
+		description string
+		remoteAddr  string
+		useHeader   string // Resolver header (default: X-Forwarded-For)
+		header      http.Header
+		want        string
+		wantErr     error
+	}{
+		{description: "remote address", remoteAddr: "192.0.2.1:1234", want: "192.0.2.1"},
+		{description: "remote address without port", remoteAddr: "192.0.2.1", want: "192.0.2.1"},
+		{description: "IPv6 remote address", remoteAddr: "[2001:db8::2]:1234", want: "2001:db8::2"},
+		{description: "invalid remote address", remoteAddr: "invalid", wantErr: ErrInvalidClientIP},
+		{
+			description: "untrusted proxy headers are ignored",
+			remoteAddr:  "192.0.2.1:1234",
+			header:      http.Header{"X-Forwarded-For": {"203.0.113.1"}},
+			want:        "192.0.2.1",
+		},
+		{
+			description: "X-Forwarded-For chain",
+			remoteAddr:  "10.0.0.1:1234",
+			header:      http.Header{"X-Forwarded-For": {"198.51.100.1, 203.0.113.1", "10.0.0.2"}},
+			want:        "203.0.113.1",
+		},
+		{
+			description: "X-Forwarded-For chain of trusted proxies",
+			remoteAddr:  "10.0.0.1:1234",
+			header:      http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			want:        "10.0.0.3",
+		},
+		{
+			description: "invalid X-Forwarded-For",
+			remoteAddr:  "10.0.0.1:1234",
+			header:      http.Header{"X-Forwarded-For": {"unknown"}},
+			want:        "10.0.0.1",
+		},
+		{
+			description: "entries left of a malformed entry are not trusted",
+			remoteAddr:  "10.0.0.1:1234",
+			header:      http.Header{"X-Forwarded-For": {"203.0.113.1, unknown, 10.0.0.2"}},
+			want:        "10.0.0.2",
+		},
+		{
+			description: "obfuscated Forwarded identifier",
+			remoteAddr:  "10.0.0.2:1234",
+			useHeader:   HeaderForwarded,
+			header:      http.Header{"Forwarded": {"for=1.2.3.4, for=unknown"}},
+			want:        "10.0.0.2",
+		},
+		{
+			description: "Forwarded",
+			remoteAddr:  "[2001:db8::1]:1234",
+			useHeader:   HeaderForwarded,
+			header: http.Header{
+				"Forwarded":       {`for=198.51.100.1;proto=https, for="[2001:db8::3]:4711";by=10.0.0.1`},
+				"X-Forwarded-For": {"203.0.113.1"},
+			},
+			want: "2001:db8::3",
+		},
+		{
+			description: "forged Forwarded is ignored",
+			remoteAddr:  "10.0.0.2:1234",
+			header: http.Header{
+				"Forwarded":       {"for=1.2.3.4"},
+				"X-Forwarded-For": {"198.51.100.7"},
+			},
+			want: "198.51.100.7",
+		},
+		{
+			description: "other headers are ignored",
+			remoteAddr:  "10.0.0.2:1234",
+			header:      http.Header{"Forwarded": {"for=1.2.3.4"}, "X-Real-Ip": {"1.2.3.4"}},
+			want:        "10.0.0.2",
+		},
+		{
+			description: "X-Real-IP",
+			remoteAddr:  "10.0.0.1:1234",
+			useHeader:   HeaderXRealIP,
+			header:      http.Header{"X-Real-Ip": {"203.0.113.1"}},
+			want:        "203.0.113.1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = test.remoteAddr
+			for k, values := range test.header {
+				req.Header[k] = values
+			}
+			res := *res
+			res.Header = test.useHeader
+			ip, err := res.ClientIP(req)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("want error %v but got %v", test.wantErr, err)
+			}
+			if test.wantErr == nil && ip.String() != test.want {
+				t.Fatalf("want %q but got %q", test.want, ip)
+			}
+		})
+	}
+
+	if _, err := NewClientIPResolver("10.0.0.0/33"); err == nil {
+		t.Fatal("want error for invalid CIDR")
+	}
+}
@@ -0,0 +1,248 @@
+package web
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Content types that are not compressed by CompressionMiddleware (matched by prefix),
+// because they are already compressed.
+var IncompressibleContentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip", "application/zstd",
+	"application/x-7z-compressed", "application/x-rar-compressed", "application/pdf",
+}
+
+// CompressionConfig configures CompressionMiddleware.
+type CompressionConfig struct {
+	Level   int // Default: gzip.DefaultCompression (zero means default, use gzip.BestSpeed for the fastest compression)
+	MinSize int // Bodies smaller than this are sent uncompressed (default: 1024 bytes)
+}
+
+// Compression middleware compresses responses with gzip or deflate depending on the Accept-Encoding header.
+// Responses are not compressed if they are small, already encoded, partial (206)
+// or if their content type is in IncompressibleContentTypes.
+// The Vary header is always set to Accept-Encoding.
+//
+// Streaming is supported: flushing sends buffered data (compressed) to the client.
+// Requests with an Upgrade header are not compressed.
+func CompressionMiddleware(config *CompressionConfig) func(http.Handler) http.Handler {
+	level := config.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	} else if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		panic(fmt.Errorf("invalid compression level: %d", level))
+	}
+	minSize := config.MinSize
+	if minSize <= 0 {
+		minSize = 1024
+	}
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !headerContainsToken(w.Header(), "Vary", "Accept-Encoding") {
+				w.Header().Add("Vary", "Accept-Encoding")
+			}
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				h.ServeHTTP(w, r) // upgraded connections (for ex: websockets) are never compressed
+				return
+			}
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, level: level, minSize: minSize, statusCode: http.StatusOK}
+			h.ServeHTTP(cw, r)
+			cw.Close() // not deferred: if the handler panics, buffered data is discarded so a 500 can still be sent
+		})
+	}
+}
+
+// Returns the preferred supported encoding ("gzip" or "deflate") or an empty string.
+func negotiateEncoding(acceptEncoding string) string {
+	best, bestQ := "", 0.0
+	wildcardQ := -1.0
+	qs := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if name == "*" {
+			wildcardQ = q
+		} else if name != "" {
+			qs[name] = q
+		}
+	}
+	for _, encoding := range []string{"gzip", "deflate"} { // in order of preference
+		q, found := qs[encoding]
+		if !found {
+			q = wildcardQ
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// Reports whether the comma-separated header values contain the given token (case-insensitive).
+func headerContainsToken(header http.Header, k, token string) bool {
+	for _, v := range header.Values(k) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Buffers the beginning of the response until it knows whether it should be compressed.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding   string
+	level      int
+	minSize    int
+	statusCode int
+	buf        []byte
+	decided    bool
+	compressor io.WriteCloser // nil if the response is not compressed
+}
+
+func (cw *compressWriter) WriteHeader(statusCode int) {
+	if cw.decided {
+		cw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if statusCode >= 100 && statusCode < 200 {
+		cw.ResponseWriter.WriteHeader(statusCode) // informational responses are sent right away
+		return
+	}
+	cw.statusCode = statusCode
+	if !bodyAllowedForStatus(statusCode) {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < cw.minSize {
+			return len(b), nil
+		}
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if cw.compressor != nil {
+		return cw.compressor.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data to the client (compressed if the content type allows it, whatever its size).
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.decide(true)
+	}
+	if f, ok := cw.compressor.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Close sends buffered data and terminates the compressed stream.
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if err := cw.decide(len(cw.buf) >= cw.minSize); err != nil {
+			return err
+		}
+	}
+	if cw.compressor != nil {
+		return cw.compressor.Close()
+	}
+	return nil
+}
+
+// Hijack sends buffered data uncompressed (if any) and takes over the connection.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if !cw.decided {
+		if len(cw.buf) > 0 {
+			if err := cw.decide(false); err != nil {
+				return nil, nil, err
+			}
+		}
+		cw.decided = true
+	}
+	return http.NewResponseController(cw.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying response writer (used by http.ResponseController).
+func (cw *compressWriter) Unwrap() http.ResponseWriter { return cw.ResponseWriter }
+
+// Writes the header and buffered data, compressing them if allowed.
+func (cw *compressWriter) decide(allowed bool) error {
+	cw.decided = true
+	header := cw.Header()
+	if header.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(cw.buf)) // sniff before compressing
+	}
+	if allowed && cw.shouldCompress() {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", cw.encoding)
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag) // compressed representation is not byte-identical
+		}
+		switch cw.encoding {
+		case "gzip":
+			cw.compressor, _ = gzip.NewWriterLevel(cw.ResponseWriter, cw.level) // level is validated by the middleware
+		case "deflate":
+			cw.compressor, _ = zlib.NewWriterLevel(cw.ResponseWriter, cw.level)
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.statusCode)
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.compressor != nil {
+		_, err = cw.compressor.Write(cw.buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf)
+	}
+	cw.buf = nil
+	return err
+}
+
+func (cw *compressWriter) shouldCompress() bool {
+	header := cw.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" || cw.statusCode == http.StatusPartialContent {
+		return false
+	}
+	if !bodyAllowedForStatus(cw.statusCode) {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	for _, prefix := range IncompressibleContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+func bodyAllowedForStatus(statusCode int) bool {
+	return statusCode != http.StatusNoContent && statusCode != http.StatusNotModified && statusCode >= 200
+}
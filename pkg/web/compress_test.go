@@ -0,0 +1,199 @@
+package web
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                         "",
+		"gzip":                     "gzip",
+		"deflate, gzip":            "gzip",
+		"gzip;q=0.5, deflate":      "deflate",
+		"gzip;q=0, deflate;q=0":    "",
+		"br, *;q=0.1":              "gzip",
+		"*;q=0.5, gzip;q=0":        "deflate",
+		"identity":                 "",
+		" GZIP ; q=1.0 , deflate ": "gzip",
+	}
+	for acceptEncoding, want := range tests {
+		if got := negotiateEncoding(acceptEncoding); got != want {
+			t.Fatalf("%q: want %q but got %q", acceptEncoding, want, got)
+		}
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	large := strings.Repeat("hello world ", 200)
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip":    func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"deflate": func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+	}
+
+	tests := []struct {
+		description    string
+		acceptEncoding string
+		contentType    string
+		body           string
+		wantEncoding   string
+	}{
+		{description: "gzip", acceptEncoding: "gzip", body: large, wantEncoding: "gzip"},
+		{description: "deflate", acceptEncoding: "deflate", body: large, wantEncoding: "deflate"},
+		{description: "not accepted", acceptEncoding: "br", body: large},
+		{description: "small body", acceptEncoding: "gzip", body: "hello"},
+		{description: "already compressed", acceptEncoding: "gzip", contentType: "image/png", body: large},
+		{description: "svg", acceptEncoding: "gzip", contentType: "image/svg+xml", body: large, wantEncoding: "gzip"},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			h := CompressionMiddleware(&CompressionConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.contentType != "" {
+					w.Header().Set("Content-Type", test.contentType)
+				}
+				w.Header().Set("ETag", `"abc"`)
+				for i := 0; i < len(test.body); i += 100 {
+					end := i + 100
+					if end > len(test.body) {
+						end = len(test.body)
+					}
+					io.WriteString(w, test.body[i:end])
+				}
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", test.acceptEncoding)
+			resrec := httptest.NewRecorder()
+			h.ServeHTTP(resrec, req)
+
+			if got := resrec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Fatalf("want Vary header but got %q", got)
+			}
+			if got := resrec.Header().Get("Content-Encoding"); got != test.wantEncoding {
+				t.Fatalf("want encoding %q but got %q", test.wantEncoding, got)
+			}
+			if resrec.Header().Get("Content-Type") == "" {
+				t.Fatal("want content type")
+			}
+			body := io.Reader(resrec.Body)
+			wantETag := `"abc"`
+			if test.wantEncoding != "" {
+				wantETag = `W/"abc"`
+				var err error
+				body, err = decoders[test.wantEncoding](body)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := resrec.Header().Get("ETag"); got != wantETag {
+				t.Fatalf("want ETag %q but got %q", wantETag, got)
+			}
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.body {
+				t.Fatalf("want body %q but got %q", test.body, got)
+			}
+		})
+	}
+
+	t.Run("can stream compressed responses through the status recorder", func(t *testing.T) {
+		flushed := make(chan struct{})
+		h := CompressionMiddleware(&CompressionConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, "data: first\n\n")
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				t.Error(err)
+			}
+			<-flushed
+			io.WriteString(w, "data: second\n\n")
+		}))
+		var srec *ResponseStatusRecorder
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			srec = NewResponseStatusRecorder(w)
+			h.ServeHTTP(srec, r)
+		}))
+		defer s.Close()
+
+		req, _ := http.NewRequest(http.MethodGet, s.URL, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		res, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		zr, err := gzip.NewReader(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, len("data: first\n\n"))
+		if _, err := io.ReadFull(zr, buf); err != nil || string(buf) != "data: first\n\n" {
+			t.Fatalf("want first event before end of response but got %q (%v)", buf, err)
+		}
+		close(flushed)
+		rest, err := io.ReadAll(zr)
+		if err != nil || string(rest) != "data: second\n\n" {
+			t.Fatalf("want second event but got %q (%v)", rest, err)
+		}
+		if srec.StatusCode != http.StatusOK || srec.BytesWritten == 0 {
+			t.Fatalf("want status and bytes recorded but got %d and %d", srec.StatusCode, srec.BytesWritten)
+		}
+	})
+
+	t.Run("should let panic recovery send a 500 when the handler panics", func(t *testing.T) {
+		h := CompressionMiddleware(&CompressionConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "partial")
+			panic("oops")
+		}))
+		h = PanicRecoveryMiddleware(func(err any, w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		})(h)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		resrec := httptest.NewRecorder()
+		h.ServeHTTP(resrec, req)
+		if resrec.Code != http.StatusInternalServerError {
+			t.Fatalf("want status %d but got %d", http.StatusInternalServerError, resrec.Code)
+		}
+		if got := resrec.Body.String(); got != "internal error\n" {
+			t.Fatalf("want body %q but got %q", "internal error\n", got)
+		}
+	})
+
+	t.Run("can hijack connections", func(t *testing.T) {
+		h := CompressionMiddleware(&CompressionConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, rw, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer conn.Close()
+			rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\nhello")
+			rw.Flush()
+		}))
+		s := httptest.NewServer(h)
+		defer s.Close()
+
+		for _, upgrade := range []string{"test", ""} {
+			req, _ := http.NewRequest(http.MethodGet, s.URL, nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			if upgrade != "" {
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("Upgrade", upgrade)
+			}
+			res, err := http.DefaultTransport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != http.StatusSwitchingProtocols {
+				t.Fatalf("upgrade %q: want status %d but got %d", upgrade, http.StatusSwitchingProtocols, res.StatusCode)
+			}
+			res.Body.Close()
+		}
+	})
+}
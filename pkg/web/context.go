@@ -0,0 +1,10 @@
+package web
+
+// Keys of the values stored in request contexts by this package.
+type ctxKey int
+
+const (
+	ctxKeyPathParams ctxKey = iota
+	ctxKeyCSRFError
+	ctxKeySession
+)
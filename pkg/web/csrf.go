@@ -0,0 +1,215 @@
+package web
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ejuju/go-utils/pkg/htmlg"
+	"github.com/ejuju/go-utils/pkg/uid"
+)
+
+var (
+	ErrCSRFOriginMismatch = errors.New("origin does not match")
+	ErrCSRFTokenMissing   = errors.New("missing CSRF token")
+	ErrCSRFTokenInvalid   = errors.New("invalid CSRF token")
+)
+
+// CSRFConfig configures CSRFMiddleware.
+type CSRFConfig struct {
+	Secret         []byte       // Required, used to sign tokens
+	CookieName     string       // Default: "_csrf"
+	FieldName      string       // Default: "csrf_token"
+	HeaderName     string       // Default: "X-CSRF-Token" (the token can be sent in this header instead of the form)
+	TrustedOrigins []string     // Optional, other origins allowed to submit requests (for ex: "https://admin.example.com")
+	OriginOnly     bool         // Only check the Origin (or Referer) header, without tokens
+	OnError        http.Handler // Default: responds with 403 Forbidden, use CSRFError to get the reason
+
+	// Optional, binds tokens to the session identifier returned (if not empty), for ex:
+	//	func(r *http.Request) string { return web.GetSession(r).ID() } (if used after SessionMiddleware)
+	// so a cookie set by an attacker (for ex: from a subdomain) can't be used with the victim's session.
+	SessionID func(r *http.Request) string
+}
+
+// CSRF middleware protects handlers against cross-site request forgery.
+//
+// Requests with an unsafe method (anything but GET, HEAD, OPTIONS and TRACE) are rejected if:
+//   - the Origin header (or Referer header for HTTPS requests without Origin) doesn't match the host or a trusted origin
+//   - the submitted token (form field or header) doesn't match the signed token stored in the CSRF cookie
+//     (signed double-submit cookie pattern, skipped if OriginOnly is set)
+//
+// The token is stored in the request context,
+// use htmlg.AddCSRFTokenInput (or CSRFToken) to include it in forms.
+// It is masked with a new random pad on each request so it can't be guessed from compressed responses (BREACH attack).
+func CSRFMiddleware(config *CSRFConfig) func(http.Handler) http.Handler {
+	if len(config.Secret) == 0 && !config.OriginOnly {
+		panic(errors.New("missing CSRF secret"))
+	}
+	cookieName := stringOrDefault(config.CookieName, "_csrf")
+	fieldName := stringOrDefault(config.FieldName, "csrf_token")
+	headerName := stringOrDefault(config.HeaderName, "X-CSRF-Token")
+	onError := config.OnError
+	if onError == nil {
+		onError = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		})
+	}
+	reject := func(err error, w http.ResponseWriter, r *http.Request) {
+		onError.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyCSRFError, err)))
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			safe := isSafeMethod(r.Method)
+			if !safe {
+				if err := checkOrigin(r, config.TrustedOrigins, config.OriginOnly); err != nil {
+					reject(err, w, r)
+					return
+				}
+			}
+			if config.OriginOnly {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			// Get the token from the cookie or issue a new one
+			sessionID := ""
+			if config.SessionID != nil {
+				sessionID = config.SessionID(r)
+			}
+			token := ""
+			if cookie, err := r.Cookie(cookieName); err == nil && verifyCSRFToken(config.Secret, sessionID, cookie.Value) {
+				token = cookie.Value
+			}
+			if token == "" {
+				token = newCSRFToken(config.Secret, sessionID)
+				http.SetCookie(w, &http.Cookie{
+					Name:     cookieName,
+					Value:    token,
+					Path:     "/",
+					Secure:   r.TLS != nil,
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+			r = r.WithContext(htmlg.ContextWithCSRFToken(r.Context(), fieldName, maskCSRFToken(token)))
+
+			if !safe {
+				submitted := r.Header.Get(headerName)
+				if submitted == "" {
+					submitted = r.PostFormValue(fieldName)
+				}
+				if submitted == "" {
+					reject(ErrCSRFTokenMissing, w, r)
+					return
+				} else if unmasked, ok := unmaskCSRFToken(submitted); !ok || !hmac.Equal(unmasked, []byte(token)) {
+					reject(ErrCSRFTokenInvalid, w, r)
+					return
+				}
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CSRFToken returns the (masked) token set by CSRFMiddleware (or an empty string).
+func CSRFToken(r *http.Request) string {
+	_, token := htmlg.CSRFTokenFromContext(r.Context())
+	return token
+}
+
+// CSRFError returns the reason why the request was rejected by CSRFMiddleware (or nil).
+// Use it in CSRFConfig.OnError.
+func CSRFError(r *http.Request) error {
+	err, _ := r.Context().Value(ctxKeyCSRFError).(error)
+	return err
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// Checks that the request comes from the same host or a trusted origin.
+// If required is false, requests without Origin and Referer headers are accepted.
+func checkOrigin(r *http.Request, trustedOrigins []string, required bool) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" && (r.TLS != nil || required) {
+		// Browsers always send a Referer for same-origin HTTPS requests (unless disabled by policy)
+		if referer, err := url.Parse(r.Header.Get("Referer")); err == nil && referer.Host != "" {
+			origin = referer.Scheme + "://" + referer.Host
+		}
+	}
+	if origin == "" {
+		if required {
+			return ErrCSRFOriginMismatch
+		}
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host) {
+		return nil
+	}
+	for _, trusted := range trustedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(trusted, "/"), origin) {
+			return nil
+		}
+	}
+	return ErrCSRFOriginMismatch
+}
+
+// Returns a random value and its signature (bound to the session ID), encoded as "value.signature".
+func newCSRFToken(secret []byte, sessionID string) string {
+	value := base64.RawURLEncoding.EncodeToString(uid.MustNewID(32))
+	return value + "." + signValue(secret, sessionID+"!"+value)
+}
+
+func verifyCSRFToken(secret []byte, sessionID, token string) bool {
+	value, signature, found := strings.Cut(token, ".")
+	return found && hmac.Equal([]byte(signature), []byte(signValue(secret, sessionID+"!"+value)))
+}
+
+// Returns the token XORed with a random pad, encoded as base64(pad + masked token).
+func maskCSRFToken(token string) string {
+	pad := uid.MustNewID(len(token))
+	masked := make([]byte, 2*len(token))
+	copy(masked, pad)
+	for i := range pad {
+		masked[len(pad)+i] = pad[i] ^ token[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+func unmaskCSRFToken(s string) ([]byte, bool) {
+	masked, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(masked) == 0 || len(masked)%2 != 0 {
+		return nil, false
+	}
+	pad, token := masked[:len(masked)/2], masked[len(masked)/2:]
+	for i := range token {
+		token[i] ^= pad[i]
+	}
+	return token, true
+}
+
+// Returns the base64-encoded HMAC-SHA256 signature of the value.
+func signValue(secret []byte, value string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func stringOrDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
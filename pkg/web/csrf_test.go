@@ -0,0 +1,172 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ejuju/go-utils/pkg/htmlg"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	var gotErr error
+	config := &CSRFConfig{
+		Secret:         []byte("secret"),
+		TrustedOrigins: []string{"https://admin.example.com"},
+		OnError: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotErr = CSRFError(r)
+			w.WriteHeader(http.StatusForbidden)
+		}),
+	}
+	h := CSRFMiddleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(htmlg.Create("form", htmlg.AddCSRFTokenInput(r.Context())).HTMLString()))
+	}))
+
+	// Get token and cookie
+	resrec := httptest.NewRecorder()
+	h.ServeHTTP(resrec, httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+	cookies := resrec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "_csrf" {
+		t.Fatalf("want CSRF cookie but got %v", cookies)
+	}
+	cookie := cookies[0]
+	token := csrfTokenFromForm(t, resrec.Body.String())
+	if token == cookie.Value {
+		t.Fatal("want masked token in form")
+	}
+
+	tests := []struct {
+		description string
+		token       string
+		header      bool
+		cookie      *http.Cookie
+		origin      string
+		wantErr     error
+	}{
+		{description: "valid form token", token: token, cookie: cookie},
+		{description: "valid header token", token: token, header: true, cookie: cookie},
+		{description: "valid token from trusted origin", token: token, cookie: cookie, origin: "https://admin.example.com"},
+		{description: "same origin", token: token, cookie: cookie, origin: "http://example.com"},
+		{description: "cross origin", token: token, cookie: cookie, origin: "https://evil.com", wantErr: ErrCSRFOriginMismatch},
+		{description: "opaque origin", token: token, cookie: cookie, origin: "null", wantErr: ErrCSRFOriginMismatch},
+		{description: "missing token", cookie: cookie, wantErr: ErrCSRFTokenMissing},
+		{description: "wrong token", token: "abc", cookie: cookie, wantErr: ErrCSRFTokenInvalid},
+		{description: "unmasked token", token: cookie.Value, cookie: cookie, wantErr: ErrCSRFTokenInvalid},
+		{description: "missing cookie", token: token, wantErr: ErrCSRFTokenInvalid},
+		{description: "forged cookie", token: "abc.def", cookie: &http.Cookie{Name: "_csrf", Value: "abc.def"}, wantErr: ErrCSRFTokenInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			gotErr = nil
+			form := url.Values{}
+			if !test.header && test.token != "" {
+				form.Set("csrf_token", test.token)
+			}
+			req := httptest.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if test.header {
+				req.Header.Set("X-CSRF-Token", test.token)
+			}
+			if test.origin != "" {
+				req.Header.Set("Origin", test.origin)
+			}
+			if test.cookie != nil {
+				req.AddCookie(test.cookie)
+			}
+			resrec := httptest.NewRecorder()
+			h.ServeHTTP(resrec, req)
+
+			wantStatus := http.StatusOK
+			if test.wantErr != nil {
+				wantStatus = http.StatusForbidden
+			}
+			if resrec.Code != wantStatus {
+				t.Fatalf("want status %d but got %d", wantStatus, resrec.Code)
+			}
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Fatalf("want error %v but got %v", test.wantErr, gotErr)
+			}
+		})
+	}
+
+	t.Run("should mask the token on each request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+		req.AddCookie(cookie)
+		resrec := httptest.NewRecorder()
+		h.ServeHTTP(resrec, req)
+		other := csrfTokenFromForm(t, resrec.Body.String())
+		if other == token {
+			t.Fatalf("want different masked tokens but got %q twice", token)
+		}
+		for _, submitted := range []string{token, other} {
+			gotErr = nil
+			form := url.Values{"csrf_token": {submitted}}
+			req := httptest.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.AddCookie(cookie)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if gotErr != nil {
+				t.Fatalf("want token %q accepted but got %v", submitted, gotErr)
+			}
+		}
+	})
+
+	t.Run("should bind tokens to the session", func(t *testing.T) {
+		config := *config
+		config.SessionID = func(r *http.Request) string { return r.Header.Get("X-Session") }
+		h := CSRFMiddleware(&config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(htmlg.Create("form", htmlg.AddCSRFTokenInput(r.Context())).HTMLString()))
+		}))
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+		req.Header.Set("X-Session", "attacker")
+		resrec := httptest.NewRecorder()
+		h.ServeHTTP(resrec, req)
+		cookie, token := resrec.Result().Cookies()[0], csrfTokenFromForm(t, resrec.Body.String())
+
+		for session, wantErr := range map[string]error{"attacker": nil, "victim": ErrCSRFTokenInvalid} {
+			gotErr = nil
+			form := url.Values{"csrf_token": {token}}
+			req := httptest.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("X-Session", session)
+			req.AddCookie(cookie)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if !errors.Is(gotErr, wantErr) {
+				t.Fatalf("%s: want error %v but got %v", session, wantErr, gotErr)
+			}
+		}
+	})
+
+	t.Run("can check origin only", func(t *testing.T) {
+		h := CSRFMiddleware(&CSRFConfig{OriginOnly: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		for referer, want := range map[string]int{
+			"":                         http.StatusForbidden,
+			"http://example.com/page":  http.StatusOK,
+			"http://evil.com/page":     http.StatusForbidden,
+			"http://example.com.evil/": http.StatusForbidden,
+		} {
+			req := httptest.NewRequest(http.MethodPost, "http://example.com/", nil)
+			if referer != "" {
+				req.Header.Set("Referer", referer)
+			}
+			resrec := httptest.NewRecorder()
+			h.ServeHTTP(resrec, req)
+			if resrec.Code != want {
+				t.Fatalf("%q: want status %d but got %d", referer, want, resrec.Code)
+			}
+		}
+	})
+}
+
+// Returns the value of the CSRF token input.
+func csrfTokenFromForm(t *testing.T, body string) string {
+	_, after, found := strings.Cut(body, `name="csrf_token" value="`)
+	token, _, _ := strings.Cut(after, `"`)
+	if !found || token == "" {
+		t.Fatalf("want token input in form but got %q", body)
+	}
+	return token
+}
@@ -0,0 +1,120 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Group represents routes sharing a path prefix, request matchers and a middleware chain.
+//
+// The prefix is stripped from the request URL path before the group routes are matched
+// (like http.StripPrefix), so route paths are relative to the group prefix.
+// Middleware is only applied to requests handled by the group.
+//
+// Requests with the group prefix that no group route matches are left to the parent routes
+// (and their 404 and 405 handlers), unless the group has its own fallback handlers.
+//
+// A group can be mounted in Routes (or in another group) using Mount.
+type Group struct {
+	prefix     string
+	matchers   []RequestMatcher
+	middleware []func(http.Handler) http.Handler
+	routes     Routes
+	handler    http.Handler // Group routes wrapped with middleware
+}
+
+// NewGroup instanciates a new group for the given path prefix and optional matchers.
+func NewGroup(prefix string, matchers ...RequestMatcher) *Group {
+	g := &Group{prefix: strings.TrimSuffix(prefix, "/"), matchers: matchers}
+	g.handler = http.HandlerFunc(g.serveRoutes)
+	return g
+}
+
+// Use appends middleware to the group middleware chain.
+// The first middleware is the outermost one.
+func (g *Group) Use(middleware ...func(http.Handler) http.Handler) *Group {
+	g.middleware = append(g.middleware, middleware...)
+	g.handler = http.HandlerFunc(g.serveRoutes)
+	for i := len(g.middleware) - 1; i >= 0; i-- {
+		g.handler = g.middleware[i](g.handler)
+	}
+	return g
+}
+
+// Handle registers a handler for requests matching the group and the given matchers.
+func (g *Group) Handle(h http.Handler, matchers ...RequestMatcher) *Route {
+	return g.routes.Handle(h, matchers...)
+}
+
+// Sets the handler used when no group route matches the request path.
+// The group then handles all requests with its prefix.
+func (g *Group) HandleNotFound(h http.Handler) { g.routes.HandleNotFound(h) }
+
+// Sets the handler used when group routes match the request path but not its method.
+func (g *Group) HandleMethodNotAllowed(h http.Handler) { g.routes.HandleMethodNotAllowed(h) }
+
+// Group creates and mounts a sub-group with a prefix relative to this group.
+func (g *Group) Group(prefix string, matchers ...RequestMatcher) *Group {
+	sub := NewGroup(prefix, matchers...)
+	g.Mount(sub)
+	return sub
+}
+
+// Mount registers a sub-group in this group.
+func (g *Group) Mount(sub *Group) { g.routes.Mount(sub) }
+
+// Mount registers a group in the list of routes.
+func (rhs *Routes) Mount(g *Group) { rhs.Handle(g, g.Match) }
+
+// Reports whether the request URL path starts with the group prefix
+// (the prefix must be followed by a slash or the end of the path), all group matchers yield true
+// and the group handles the request (a group route matches it or the group has a matching fallback handler).
+func (g *Group) Match(r *http.Request) bool {
+	if r.URL.Path != g.prefix && !strings.HasPrefix(r.URL.Path, g.prefix+"/") {
+		return false
+	}
+	for _, matcher := range g.matchers {
+		if !matcher(r) {
+			return false
+		}
+	}
+	return g.handles(g.stripPrefix(r))
+}
+
+// Reports whether the group routes handle the request (with the group prefix stripped).
+func (g *Group) handles(r *http.Request) bool {
+	r = withPathParams(r) // keep parameters of group routes out of the parent routes parameters
+	switch {
+	case g.routes.match(r) != nil, g.routes.hasFallback(routeFallbackNotFound):
+		return true
+	case r.Method == http.MethodHead && g.routes.match(withMethod(r, http.MethodGet)) != nil:
+		return true
+	case g.routes.hasFallback(routeFallbackMethodNotAllowed):
+		return len(g.routes.allowedMethods(r)) > 0
+	}
+	return false
+}
+
+func (g *Group) ServeHTTP(w http.ResponseWriter, r *http.Request) { g.handler.ServeHTTP(w, r) }
+
+// Strips the group prefix from the request URL path and serves the group routes.
+func (g *Group) serveRoutes(w http.ResponseWriter, r *http.Request) {
+	g.routes.ServeHTTP(w, g.stripPrefix(r))
+}
+
+// Returns a shallow copy of the request with the group prefix stripped from the URL path.
+func (g *Group) stripPrefix(r *http.Request) *http.Request {
+	r2 := r.WithContext(r.Context())
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = strings.TrimPrefix(r.URL.Path, g.prefix)
+	r2.URL.RawPath = strings.TrimPrefix(r.URL.RawPath, g.prefix)
+	if r2.URL.Path == "" {
+		r2.URL.Path = "/"
+	}
+	if r.URL.RawPath != "" && r2.URL.RawPath == "" {
+		r2.URL.RawPath = "/"
+	}
+	return r2
+}
@@ -0,0 +1,239 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/ejuju/go-utils/pkg/htmlg"
+)
+
+const SchemaOrgContext = "https://schema.org"
+
+// Item availability (for Offer.Availability).
+const (
+	AvailabilityInStock    = "https://schema.org/InStock"
+	AvailabilityOutOfStock = "https://schema.org/OutOfStock"
+	AvailabilityPreOrder   = "https://schema.org/PreOrder"
+)
+
+// JSONLDScript returns a <script type="application/ld+json"> element holding the given schema.org items
+// (for ex: WebSite, Organization, Article, BreadcrumbList, Product or FAQPage).
+// Several items are grouped in a @graph.
+// Add it to the page <head>.
+func JSONLDScript(items ...any) (*htmlg.Element, error) {
+	if len(items) == 0 {
+		return nil, errors.New("missing JSON-LD item")
+	}
+	doc := jsonLDObject{"@context": SchemaOrgContext}
+	if len(items) == 1 {
+		raw, err := json.Marshal(items[0])
+		if err != nil {
+			return nil, fmt.Errorf("marshal JSON-LD: %w", err)
+		}
+		err = json.Unmarshal(raw, &doc)
+		if err != nil {
+			return nil, fmt.Errorf("JSON-LD item must be an object: %w", err)
+		}
+		doc["@context"] = SchemaOrgContext
+	} else {
+		doc["@graph"] = items
+	}
+	raw, err := json.Marshal(doc) // < > and & are escaped so the content can't close the script element
+	if err != nil {
+		return nil, fmt.Errorf("marshal JSON-LD: %w", err)
+	}
+	return htmlg.Create("script", htmlg.SetAttr([2]string{"type", "application/ld+json"}), htmlg.Wrap(htmlg.String(raw))), nil
+}
+
+// MustJSONLDScript is like JSONLDScript but panics on error.
+func MustJSONLDScript(items ...any) *htmlg.Element {
+	e, err := JSONLDScript(items...)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// WebSite represents a schema.org WebSite.
+type WebSite struct {
+	Name        string
+	URL         string
+	Description string
+	InLanguage  string
+	SearchURL   string // Optional, URL template of the search page, for ex: "https://example.com/search?q={search_term_string}"
+}
+
+func (v WebSite) MarshalJSON() ([]byte, error) {
+	o := newJSONLDObject("WebSite").
+		set("name", v.Name).
+		set("url", v.URL).
+		set("description", v.Description).
+		set("inLanguage", v.InLanguage)
+	if v.SearchURL != "" {
+		o.set("potentialAction", newJSONLDObject("SearchAction").
+			set("target", v.SearchURL).
+			set("query-input", "required name=search_term_string"))
+	}
+	return json.Marshal(o)
+}
+
+// Organization represents a schema.org Organization.
+type Organization struct {
+	Name      string
+	URL       string
+	Logo      string
+	Email     string
+	Telephone string
+	SameAs    []string // URLs of social media profiles, etc.
+}
+
+func (v Organization) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newJSONLDObject("Organization").
+		set("name", v.Name).
+		set("url", v.URL).
+		set("logo", v.Logo).
+		set("email", v.Email).
+		set("telephone", v.Telephone).
+		set("sameAs", v.SameAs))
+}
+
+// Person represents a schema.org Person.
+type Person struct {
+	Name string
+	URL  string
+}
+
+func (v Person) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newJSONLDObject("Person").set("name", v.Name).set("url", v.URL))
+}
+
+// Article represents a schema.org Article.
+type Article struct {
+	Headline      string
+	Description   string
+	URL           string
+	Images        []string
+	DatePublished time.Time
+	DateModified  time.Time
+	Authors       []Person
+	Publisher     *Organization
+}
+
+func (v Article) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newJSONLDObject("Article").
+		set("headline", v.Headline).
+		set("description", v.Description).
+		set("mainEntityOfPage", v.URL).
+		set("image", v.Images).
+		set("datePublished", v.DatePublished).
+		set("dateModified", v.DateModified).
+		set("author", v.Authors).
+		set("publisher", v.Publisher))
+}
+
+// BreadcrumbList represents a schema.org BreadcrumbList (items are ordered from the root page).
+type BreadcrumbList []Breadcrumb
+
+type Breadcrumb struct {
+	Name string
+	URL  string // Optional for the last item (current page)
+}
+
+func (v BreadcrumbList) MarshalJSON() ([]byte, error) {
+	items := make([]jsonLDObject, len(v))
+	for i, b := range v {
+		items[i] = newJSONLDObject("ListItem").set("position", i+1).set("name", b.Name).set("item", b.URL)
+	}
+	return json.Marshal(newJSONLDObject("BreadcrumbList").set("itemListElement", items))
+}
+
+// Product represents a schema.org Product.
+type Product struct {
+	Name            string
+	Description     string
+	Images          []string
+	SKU             string
+	Brand           string
+	Offers          []Offer
+	AggregateRating *AggregateRating
+}
+
+// Offer represents a schema.org Offer.
+type Offer struct {
+	Price         float64
+	PriceCurrency string // ISO 4217 currency code, for ex: "EUR"
+	Availability  string // For ex: AvailabilityInStock
+	URL           string
+}
+
+// AggregateRating represents a schema.org AggregateRating.
+type AggregateRating struct {
+	RatingValue float64
+	ReviewCount int
+}
+
+func (v Product) MarshalJSON() ([]byte, error) {
+	o := newJSONLDObject("Product").
+		set("name", v.Name).
+		set("description", v.Description).
+		set("image", v.Images).
+		set("sku", v.SKU)
+	if v.Brand != "" {
+		o.set("brand", newJSONLDObject("Brand").set("name", v.Brand))
+	}
+	offers := make([]jsonLDObject, len(v.Offers))
+	for i, offer := range v.Offers {
+		offers[i] = newJSONLDObject("Offer").
+			set("price", strconv.FormatFloat(offer.Price, 'f', 2, 64)).
+			set("priceCurrency", offer.PriceCurrency).
+			set("availability", offer.Availability).
+			set("url", offer.URL)
+	}
+	o.set("offers", offers)
+	if v.AggregateRating != nil {
+		o.set("aggregateRating", newJSONLDObject("AggregateRating").
+			set("ratingValue", v.AggregateRating.RatingValue).
+			set("reviewCount", v.AggregateRating.ReviewCount))
+	}
+	return json.Marshal(o)
+}
+
+// FAQPage represents a schema.org FAQPage.
+type FAQPage []FAQ
+
+type FAQ struct {
+	Question string
+	Answer   string // May contain HTML
+}
+
+func (v FAQPage) MarshalJSON() ([]byte, error) {
+	questions := make([]jsonLDObject, len(v))
+	for i, faq := range v {
+		questions[i] = newJSONLDObject("Question").
+			set("name", faq.Question).
+			set("acceptedAnswer", newJSONLDObject("Answer").set("text", faq.Answer))
+	}
+	return json.Marshal(newJSONLDObject("FAQPage").set("mainEntity", questions))
+}
+
+// JSON-LD object (keys are sorted when marshaled, so "@context" and "@type" come first).
+type jsonLDObject map[string]any
+
+func newJSONLDObject(typ string) jsonLDObject { return jsonLDObject{"@type": typ} }
+
+// Sets the property unless the value is empty (times are formatted as RFC 3339).
+func (o jsonLDObject) set(k string, v any) jsonLDObject {
+	if t, ok := v.(time.Time); ok && !t.IsZero() {
+		v = t.Format(time.RFC3339)
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.IsZero() || ((rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map) && rv.Len() == 0) {
+		return o
+	}
+	o[k] = v
+	return o
+}
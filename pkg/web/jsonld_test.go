@@ -0,0 +1,90 @@
+package web
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLD(t *testing.T) {
+	publishedAt := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		description string
+		item        any
+		want        string
+	}{
+		{
+			description: "website",
+			item:        WebSite{Name: "Example", URL: "https://example.com", SearchURL: "https://example.com/search?q={search_term_string}"},
+			want:        `{"@type":"WebSite","name":"Example","potentialAction":{"@type":"SearchAction","query-input":"required name=search_term_string","target":"https://example.com/search?q={search_term_string}"},"url":"https://example.com"}`,
+		},
+		{
+			description: "organization",
+			item:        Organization{Name: "Example", URL: "https://example.com", SameAs: []string{"https://github.com/example"}},
+			want:        `{"@type":"Organization","name":"Example","sameAs":["https://github.com/example"],"url":"https://example.com"}`,
+		},
+		{
+			description: "article",
+			item: Article{
+				Headline:      "Hello",
+				DatePublished: publishedAt,
+				Authors:       []Person{{Name: "Jane"}},
+				Publisher:     &Organization{Name: "Example"},
+			},
+			want: `{"@type":"Article","author":[{"@type":"Person","name":"Jane"}],"datePublished":"2024-01-02T15:04:05Z","headline":"Hello","publisher":{"@type":"Organization","name":"Example"}}`,
+		},
+		{
+			description: "breadcrumb list",
+			item:        BreadcrumbList{{Name: "Home", URL: "https://example.com/"}, {Name: "Blog"}},
+			want:        `{"@type":"BreadcrumbList","itemListElement":[{"@type":"ListItem","item":"https://example.com/","name":"Home","position":1},{"@type":"ListItem","name":"Blog","position":2}]}`,
+		},
+		{
+			description: "product",
+			item: Product{
+				Name:            "Shoe",
+				Brand:           "Acme",
+				Offers:          []Offer{{Price: 19.9, PriceCurrency: "EUR", Availability: AvailabilityInStock}},
+				AggregateRating: &AggregateRating{RatingValue: 4.5, ReviewCount: 12},
+			},
+			want: `{"@type":"Product","aggregateRating":{"@type":"AggregateRating","ratingValue":4.5,"reviewCount":12},"brand":{"@type":"Brand","name":"Acme"},"name":"Shoe","offers":[{"@type":"Offer","availability":"https://schema.org/InStock","price":"19.90","priceCurrency":"EUR"}]}`,
+		},
+		{
+			description: "FAQ page",
+			item:        FAQPage{{Question: "Why?", Answer: "Because."}},
+			want:        `{"@type":"FAQPage","mainEntity":[{"@type":"Question","acceptedAnswer":{"@type":"Answer","text":"Because."},"name":"Why?"}]}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run("can marshal "+test.description, func(t *testing.T) {
+			got, err := json.Marshal(test.item)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Fatalf("want %s but got %s", test.want, got)
+			}
+		})
+	}
+
+	t.Run("can generate a script element", func(t *testing.T) {
+		got := MustJSONLDScript(Organization{Name: "</script><script>alert(1)</script>"}).HTMLString()
+		want := `<script type="application/ld+json">{"@context":"https://schema.org","@type":"Organization","name":"\u003c/script\u003e\u003cscript\u003ealert(1)\u003c/script\u003e"}</script>`
+		if got != want {
+			t.Fatalf("want %q but got %q", want, got)
+		}
+	})
+
+	t.Run("can group several items in a graph", func(t *testing.T) {
+		got := MustJSONLDScript(WebSite{Name: "Example"}, Organization{Name: "Example"}).HTMLString()
+		if !strings.Contains(got, `{"@context":"https://schema.org","@graph":[{"@type":"WebSite","name":"Example"},{"@type":"Organization","name":"Example"}]}`) {
+			t.Fatalf("unexpected script: %q", got)
+		}
+	})
+
+	t.Run("should reject non-object items", func(t *testing.T) {
+		if _, err := JSONLDScript("hello"); err == nil {
+			t.Fatal("want error")
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PathPattern represents a parsed URL path pattern.
+//
+// Patterns are made of slash-separated segments, each segment can be:
+//   - a literal string (for ex: "posts")
+//   - a named parameter matching any non-empty segment (for ex: "{id}")
+//   - a wildcard matching any non-empty segment ("*")
+//   - a named parameter matching the rest of the path, only allowed last (for ex: "{path...}")
+//
+// A trailing slash is optional: "/posts/{id}" matches both "/posts/1" and "/posts/1/".
+type PathPattern struct {
+	raw      string
+	segments []patternSegment
+}
+
+type patternSegment struct {
+	literal  string
+	param    string // empty for literals and wildcards
+	wildcard bool
+	rest     bool
+}
+
+// ParsePathPattern parses a path pattern (see PathPattern for the syntax).
+func ParsePathPattern(pattern string) (*PathPattern, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return nil, fmt.Errorf("pattern %q must start with a slash", pattern)
+	}
+	p := &PathPattern{raw: pattern}
+	names := map[string]struct{}{}
+	parts := splitPath(pattern)
+	for i, part := range parts {
+		seg := patternSegment{}
+		switch {
+		case part == "*":
+			seg.wildcard = true
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+			seg.param = part[1 : len(part)-1]
+			if strings.HasSuffix(seg.param, "...") {
+				if i != len(parts)-1 {
+					return nil, fmt.Errorf("pattern %q: %q must be the last segment", pattern, part)
+				}
+				seg.param, seg.rest = strings.TrimSuffix(seg.param, "..."), true
+			}
+			if seg.param == "" {
+				return nil, fmt.Errorf("pattern %q: empty parameter name", pattern)
+			}
+			if _, ok := names[seg.param]; ok {
+				return nil, fmt.Errorf("pattern %q: duplicate parameter name %q", pattern, seg.param)
+			}
+			names[seg.param] = struct{}{}
+		case strings.ContainsAny(part, "{}"):
+			return nil, fmt.Errorf("pattern %q: invalid segment %q", pattern, part)
+		default:
+			seg.literal = part
+		}
+		p.segments = append(p.segments, seg)
+	}
+	return p, nil
+}
+
+// MustParsePathPattern is like ParsePathPattern but panics on error.
+func MustParsePathPattern(pattern string) *PathPattern {
+	p, err := ParsePathPattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p *PathPattern) String() string { return p.raw }
+
+// Match reports whether the given escaped URL path matches the pattern
+// and returns the extracted (unescaped) parameters.
+func (p *PathPattern) Match(escapedPath string) (map[string]string, bool) {
+	parts := splitPath(escapedPath)
+	params := map[string]string{}
+	for i, seg := range p.segments {
+		if seg.rest {
+			v, err := url.PathUnescape(strings.Join(parts[i:], "/"))
+			if err != nil {
+				return nil, false
+			}
+			params[seg.param] = v
+			return params, true
+		}
+		if i >= len(parts) || parts[i] == "" {
+			return nil, false
+		}
+		v, err := url.PathUnescape(parts[i])
+		if err != nil {
+			return nil, false
+		}
+		switch {
+		case seg.param != "":
+			params[seg.param] = v
+		case seg.wildcard:
+		case seg.literal != v:
+			return nil, false
+		}
+	}
+	return params, len(parts) == len(p.segments)
+}
+
+// Returns path segments, ignoring leading and trailing slashes.
+func splitPath(path string) []string {
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "/"), "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// Checks if the request URL path matches the provided pattern (see PathPattern for the syntax).
+// When used with Routes, extracted parameters are available to the handler through PathParam.
+// Panics if the pattern is invalid.
+func MatchPattern(pattern string) RequestMatcher {
+	p := MustParsePathPattern(pattern)
+	return func(r *http.Request) bool {
+		params, ok := p.Match(r.URL.EscapedPath())
+		if ok {
+			if holder, found := r.Context().Value(ctxKeyPathParams).(*pathParams); found {
+				holder.values = params
+			}
+		}
+		return ok
+	}
+}
+
+// PathParam returns the value of a path parameter extracted by MatchPattern.
+// Parameters set by parent routes (when nesting Routes) are also available.
+// Returns an empty string if the parameter is not found.
+func PathParam(r *http.Request, name string) string {
+	holder, _ := r.Context().Value(ctxKeyPathParams).(*pathParams)
+	for ; holder != nil; holder = holder.parent {
+		if v, ok := holder.values[name]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// Holds path parameters extracted while matching routes for a given request.
+type pathParams struct {
+	values map[string]string
+	parent *pathParams
+}
+
+// Returns a shallow copy of the request with a new path parameters holder in its context.
+func withPathParams(r *http.Request) *http.Request {
+	holder := &pathParams{}
+	holder.parent, _ = r.Context().Value(ctxKeyPathParams).(*pathParams)
+	return r.WithContext(context.WithValue(r.Context(), ctxKeyPathParams, holder))
+}
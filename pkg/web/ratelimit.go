@@ -0,0 +1,293 @@
+package web
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ejuju/go-utils/pkg/kv"
+)
+
+// RateLimit configures a token bucket:
+// a client can send up to Limit requests at once and the bucket is refilled entirely over Period.
+// For ex: RateLimit{Limit: 60, Period: time.Minute} allows bursts of 60 requests and 1 request per second.
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+}
+
+// RateLimitResult reports the state of a bucket after attempting to take a token.
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int           // Number of tokens left
+	Reset      time.Duration // Time until the bucket is full again
+	RetryAfter time.Duration // Time until a token is available (zero if allowed)
+}
+
+// RateLimitStore stores token buckets by key.
+type RateLimitStore interface {
+	Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error)
+}
+
+// Token bucket state.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Refills the bucket according to the elapsed time and attempts to take a token.
+// A zero bucket is considered full.
+func (b tokenBucket) take(limit RateLimit, now time.Time) (tokenBucket, RateLimitResult) {
+	capacity := float64(limit.Limit)
+	rate := capacity / limit.Period.Seconds() // tokens per second
+	if b.last.IsZero() {
+		b.tokens = capacity
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*rate)
+	}
+	b.last = now
+
+	res := RateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration((capacity - b.tokens) / rate * float64(time.Second))
+	return b, res
+}
+
+// MemoryRateLimitStore stores token buckets in memory.
+// Full buckets are periodically removed to free memory.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]memoryTokenBucket
+	lastSweep time.Time
+}
+
+// Token bucket with the time at which it is full again.
+type memoryTokenBucket struct {
+	tokenBucket
+	full time.Time
+}
+
+// Minimum duration between two sweeps of full buckets.
+const rateLimitSweepInterval = time.Minute
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: map[string]memoryTokenBucket{}}
+}
+
+func (s *MemoryRateLimitStore) Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Remove buckets that have been refilled entirely
+	// (each bucket keeps its own refill time since limiters with different periods can share the store)
+	if now.Sub(s.lastSweep) > rateLimitSweepInterval {
+		for k, b := range s.buckets {
+			if !now.Before(b.full) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, res := s.buckets[key].take(limit, now)
+	s.buckets[key] = memoryTokenBucket{tokenBucket: b, full: now.Add(res.Reset)}
+	return res, nil
+}
+
+// KVRateLimitStore stores token buckets in a kv.DB so limits are kept across restarts.
+// Full buckets are periodically deleted.
+//
+// The database file is append-only: each request (and deletion) still appends a row to it.
+// Compact the file while the database isn't used, for ex. on startup before serving requests:
+// open the database, write it to a temporary file with kv.DB.CompactTo, close it,
+// rename the temporary file to the database file and open the database again.
+// Prefer MemoryRateLimitStore for limiters receiving many requests.
+type KVRateLimitStore struct {
+	db        *kv.DB
+	prefix    string
+	lastSweep time.Time // protected by the database lock
+}
+
+// NewKVRateLimitStore instanciates a new store, bucket keys are prefixed with the given prefix.
+func NewKVRateLimitStore(db *kv.DB, prefix string) *KVRateLimitStore {
+	return &KVRateLimitStore{db: db, prefix: prefix}
+}
+
+func (s *KVRateLimitStore) Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	k := []byte(s.prefix + hex.EncodeToString([]byte(key))) // hex-encode to avoid forbidden characters
+	s.db.Lock()
+	defer s.db.Unlock()
+
+	if now.Sub(s.lastSweep) > rateLimitSweepInterval {
+		err := s.sweep(now)
+		if err != nil {
+			return RateLimitResult{}, fmt.Errorf("sweep buckets: %w", err)
+		}
+		s.lastSweep = now
+	}
+
+	b, _, err := s.get(k)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	b, res := b.take(limit, now)
+	v := strconv.FormatFloat(b.tokens, 'g', -1, 64) +
+		" " + strconv.FormatInt(b.last.UnixNano(), 10) +
+		" " + strconv.FormatInt(now.Add(res.Reset).UnixNano(), 10)
+	err = s.db.Put(k, []byte(v))
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("put bucket: %w", err)
+	}
+	return res, nil
+}
+
+// Returns the bucket and the time at which it is full again (a zero bucket if the key doesn't exist).
+func (s *KVRateLimitStore) get(k []byte) (tokenBucket, time.Time, error) {
+	if !s.db.KeyExists(k) {
+		return tokenBucket{}, time.Time{}, nil
+	}
+	v, err := s.db.Get(k)
+	if err != nil {
+		return tokenBucket{}, time.Time{}, fmt.Errorf("get bucket: %w", err)
+	}
+	b, full, err := parseTokenBucket(string(v))
+	if err != nil {
+		return tokenBucket{}, time.Time{}, fmt.Errorf("parse bucket %q: %w", v, err)
+	}
+	return b, full, nil
+}
+
+// Deletes the buckets that have been refilled entirely.
+func (s *KVRateLimitStore) sweep(now time.Time) error {
+	keys := [][]byte{}
+	s.db.ForEachKey(func(k []byte) (stop bool) {
+		if strings.HasPrefix(string(k), s.prefix) {
+			keys = append(keys, k)
+		}
+		return false
+	})
+	batch := s.db.NewBatch()
+	for _, k := range keys {
+		_, full, err := s.get(k)
+		if err != nil {
+			return err
+		}
+		if now.Before(full) {
+			continue
+		}
+		err = batch.Delete(k)
+		if err != nil {
+			return fmt.Errorf("delete bucket: %w", err)
+		}
+	}
+	return s.db.WriteBatch(batch)
+}
+
+// Parses a bucket encoded as "{tokens} {unix nano timestamp} {unix nano timestamp of full bucket}".
+func parseTokenBucket(s string) (tokenBucket, time.Time, error) {
+	parts := strings.Split(s, " ")
+	if len(parts) != 3 {
+		return tokenBucket{}, time.Time{}, fmt.Errorf("want 3 fields but got %d", len(parts))
+	}
+	tokens, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return tokenBucket{}, time.Time{}, err
+	}
+	last, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return tokenBucket{}, time.Time{}, err
+	}
+	full, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return tokenBucket{}, time.Time{}, err
+	}
+	return tokenBucket{tokens: tokens, last: time.Unix(0, last)}, time.Unix(0, full), nil
+}
+
+// RateLimitingConfig configures RateLimitingMiddleware.
+type RateLimitingConfig struct {
+	RateLimit
+	Name    string                           // Optional, prefixes keys with "{name}:" (useful when sharing a store between routes)
+	Key     func(r *http.Request) string     // Default: RateLimitKeyIP
+	Store   RateLimitStore                   // Default: new MemoryRateLimitStore
+	OnLimit http.Handler                     // Default: responds with 429 Too Many Requests
+	OnError func(err error, r *http.Request) // Optional, called when the store fails (the request is still served)
+}
+
+// RateLimitKeyIP identifies clients by the remote IP address (using DefaultClientIPResolver).
+// If the address can't be parsed, the raw remote address is used (so such clients don't share a bucket).
+// Behind a proxy, use a key based on a ClientIPResolver trusting the proxy instead.
+func RateLimitKeyIP(r *http.Request) string {
+	ip, err := DefaultClientIPResolver.ClientIP(r)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip.String()
+}
+
+// Rate limiting middleware limits the number of requests per client using a token bucket.
+// It sets the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers
+// and the Retry-After header when the limit is reached.
+//
+// Each call creates a new limiter, so limits can be set per route, for ex:
+//
+//	routes.Handle(web.Wrap(h, web.RateLimitingMiddleware(&web.RateLimitingConfig{...})), web.MatchPath("/login"))
+func RateLimitingMiddleware(config *RateLimitingConfig) func(http.Handler) http.Handler {
+	if config.Limit <= 0 || config.Period <= 0 {
+		panic(fmt.Errorf("invalid rate limit: %d per %s", config.Limit, config.Period))
+	}
+	key := config.Key
+	if key == nil {
+		key = RateLimitKeyIP
+	}
+	store := config.Store
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+	prefix := ""
+	if config.Name != "" {
+		prefix = config.Name + ":"
+	}
+	onLimit := config.OnLimit
+	if onLimit == nil {
+		onLimit = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		})
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := store.Take(prefix+key(r), config.RateLimit, time.Now())
+			if err != nil {
+				if config.OnError != nil {
+					config.OnError(err, r)
+				}
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(config.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				onLimit.ServeHTTP(w, r)
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int { return int(math.Ceil(d.Seconds())) }
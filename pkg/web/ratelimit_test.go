@@ -0,0 +1,141 @@
+package web
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ejuju/go-utils/pkg/kv"
+)
+
+func TestRateLimitStores(t *testing.T) {
+	db, err := kv.NewDB(filepath.Join(t.TempDir(), "test.db"), kv.DefaultFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	stores := map[string]RateLimitStore{
+		"memory": NewMemoryRateLimitStore(),
+		"kv":     NewKVRateLimitStore(db, "ratelimit/"),
+	}
+	for name, store := range stores {
+		t.Run("can limit and refill buckets with "+name+" store", func(t *testing.T) {
+			limit := RateLimit{Limit: 2, Period: 10 * time.Second} // 1 token every 5 seconds
+			now := time.Now()
+
+			tests := []struct {
+				at             time.Duration
+				wantAllowed    bool
+				wantRemaining  int
+				wantRetryAfter time.Duration
+			}{
+				{at: 0, wantAllowed: true, wantRemaining: 1},
+				{at: 0, wantAllowed: true, wantRemaining: 0},
+				{at: time.Second, wantAllowed: false, wantRemaining: 0, wantRetryAfter: 4 * time.Second},
+				{at: 5 * time.Second, wantAllowed: true, wantRemaining: 0},
+				{at: time.Minute, wantAllowed: true, wantRemaining: 1},
+			}
+			for i, test := range tests {
+				res, err := store.Take("client 1", limit, now.Add(test.at))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.Allowed != test.wantAllowed || res.Remaining != test.wantRemaining {
+					t.Fatalf("take %d: want allowed=%v remaining=%d but got %+v", i, test.wantAllowed, test.wantRemaining, res)
+				}
+				if (res.RetryAfter - test.wantRetryAfter).Abs() > time.Millisecond {
+					t.Fatalf("take %d: want retry after %s but got %s", i, test.wantRetryAfter, res.RetryAfter)
+				}
+			}
+
+			// Other keys should have their own bucket
+			res, err := store.Take("client 2", limit, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !res.Allowed {
+				t.Fatal("want request allowed for another key")
+			}
+		})
+	}
+
+	stores = map[string]RateLimitStore{
+		"memory": NewMemoryRateLimitStore(),
+		"kv":     NewKVRateLimitStore(db, "sweep/"),
+	}
+	for name, store := range stores {
+		t.Run("should not sweep buckets of limiters with a longer period with "+name+" store", func(t *testing.T) {
+			slow := RateLimit{Limit: 1, Period: time.Hour}
+			fast := RateLimit{Limit: 1, Period: time.Second}
+			now := time.Now()
+
+			if res, _ := store.Take("slow", slow, now); !res.Allowed {
+				t.Fatal("want first request allowed")
+			}
+			store.Take("fast", fast, now.Add(2*time.Minute)) // triggers a sweep
+			if res, _ := store.Take("slow", slow, now.Add(2*time.Minute)); res.Allowed {
+				t.Fatal("want second request limited")
+			}
+		})
+	}
+
+	t.Run("should delete full buckets from kv store", func(t *testing.T) {
+		store := NewKVRateLimitStore(db, "delete/")
+		limit := RateLimit{Limit: 1, Period: time.Second}
+		now := time.Now()
+
+		store.Take("client 1", limit, now)
+		store.Take("client 2", limit, now.Add(2*time.Minute)) // triggers a sweep
+		if k := "delete/" + hex.EncodeToString([]byte("client 1")); db.KeyExists([]byte(k)) {
+			t.Fatalf("want bucket %q deleted", k)
+		}
+		if k := "delete/" + hex.EncodeToString([]byte("client 2")); !db.KeyExists([]byte(k)) {
+			t.Fatalf("want bucket %q kept", k)
+		}
+	})
+}
+
+func TestRateLimitingMiddleware(t *testing.T) {
+	t.Run("should respond with 429 and rate limit headers once the limit is reached", func(t *testing.T) {
+		h := Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), RateLimitingMiddleware(&RateLimitingConfig{
+			RateLimit: RateLimit{Limit: 2, Period: time.Minute},
+		}))
+
+		for i, wantStatus := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+			resrec := httptest.NewRecorder()
+			h.ServeHTTP(resrec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if resrec.Code != wantStatus {
+				t.Fatalf("request %d: want status %d but got %d", i, wantStatus, resrec.Code)
+			}
+			if resrec.Header().Get("RateLimit-Limit") != "2" {
+				t.Fatalf("request %d: want RateLimit-Limit header %q but got %q", i, "2", resrec.Header().Get("RateLimit-Limit"))
+			}
+			if wantStatus == http.StatusTooManyRequests && resrec.Header().Get("Retry-After") != "30" {
+				t.Fatalf("request %d: want Retry-After header %q but got %q", i, "30", resrec.Header().Get("Retry-After"))
+			}
+		}
+
+		// Requests from another IP address should not be limited
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "127.0.0.2:1234"
+		resrec := httptest.NewRecorder()
+		h.ServeHTTP(resrec, req)
+		if resrec.Code != http.StatusOK {
+			t.Fatalf("want status %d but got %d", http.StatusOK, resrec.Code)
+		}
+	})
+
+	t.Run("should not share a bucket between unparsable remote addresses", func(t *testing.T) {
+		for remoteAddr, want := range map[string]string{"192.0.2.1:1234": "192.0.2.1", "pipe-1": "pipe-1", "pipe-2": "pipe-2"} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = remoteAddr
+			if got := RateLimitKeyIP(req); got != want {
+				t.Fatalf("want key %q but got %q", want, got)
+			}
+		}
+	})
+}
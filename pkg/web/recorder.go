@@ -0,0 +1,100 @@
+package web
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ResponseStatusRecorder records the status code and number of bytes written to the response.
+// Use NewResponseStatusRecorder to get a recorder reporting 200 when the handler doesn't write anything.
+//
+// The recorder forwards Flush, Hijack and ReadFrom to the underlying response writer,
+// so features like server-sent events and websockets keep working behind it.
+type ResponseStatusRecorder struct {
+	http.ResponseWriter
+	StatusCode   int
+	BytesWritten int
+	BeforeHeader func() // Optional, called once right before the final header is sent (for ex: to set cookies)
+	wroteHeader  bool
+}
+
+// NewResponseStatusRecorder returns a recorder with a default status code of 200.
+func NewResponseStatusRecorder(w http.ResponseWriter) *ResponseStatusRecorder {
+	return &ResponseStatusRecorder{ResponseWriter: w, StatusCode: http.StatusOK}
+}
+
+func (srec *ResponseStatusRecorder) WriteHeader(statusCode int) {
+	// Only record the first final status code (informational ones may be sent before it)
+	if !srec.wroteHeader && (statusCode < 100 || statusCode >= 200) {
+		srec.StatusCode = statusCode
+		srec.wroteHeader = true
+		srec.callBeforeHeader()
+	}
+	srec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (srec *ResponseStatusRecorder) Write(b []byte) (int, error) {
+	srec.recordImplicitHeader()
+	n, err := srec.ResponseWriter.Write(b)
+	srec.BytesWritten += n
+	return n, err
+}
+
+// Reports whether the header was written (explicitly or by a call to Write).
+func (srec *ResponseStatusRecorder) WroteHeader() bool { return srec.wroteHeader }
+
+// Records a 200 status if the header wasn't written yet (writing the body implicitly sends the header).
+func (srec *ResponseStatusRecorder) recordImplicitHeader() {
+	if !srec.wroteHeader {
+		srec.StatusCode = http.StatusOK
+		srec.wroteHeader = true
+		srec.callBeforeHeader()
+	}
+}
+
+func (srec *ResponseStatusRecorder) callBeforeHeader() {
+	if srec.BeforeHeader != nil {
+		srec.BeforeHeader()
+	}
+}
+
+// Unwrap returns the underlying response writer (used by http.ResponseController).
+func (srec *ResponseStatusRecorder) Unwrap() http.ResponseWriter { return srec.ResponseWriter }
+
+// Flush sends buffered data to the client (if the underlying response writer supports it).
+// Flushing sends the header, so a 200 status is recorded if no status was written before.
+func (srec *ResponseStatusRecorder) Flush() { srec.FlushError() }
+
+// FlushError is like Flush but returns http.ErrNotSupported if the underlying response writer can't flush
+// (used by http.ResponseController).
+func (srec *ResponseStatusRecorder) FlushError() error {
+	srec.recordImplicitHeader()
+	return http.NewResponseController(srec.ResponseWriter).Flush()
+}
+
+// Hijack takes over the connection (or returns http.ErrNotSupported if the underlying response writer can't).
+func (srec *ResponseStatusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(srec.ResponseWriter).Hijack()
+	if err == nil && !srec.wroteHeader {
+		srec.StatusCode = http.StatusSwitchingProtocols
+		srec.wroteHeader = true
+		srec.callBeforeHeader() // the header is now written by the hijacker, let the hook run anyway (for ex: to save a session)
+	}
+	return conn, rw, err
+}
+
+// ReadFrom copies src to the response, using the underlying io.ReaderFrom if available (for ex: to use sendfile).
+func (srec *ResponseStatusRecorder) ReadFrom(src io.Reader) (int64, error) {
+	srec.recordImplicitHeader()
+	var n int64
+	var err error
+	if rf, ok := srec.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{srec.ResponseWriter}, src) // hide ReadFrom to avoid recursion
+	}
+	srec.BytesWritten += int(n)
+	return n, err
+}
@@ -0,0 +1,170 @@
+package web
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestResponseStatusRecorder(t *testing.T) {
+	t.Run("should report 200 when the handler doesn't write a status", func(t *testing.T) {
+		resrec := NewResponseStatusRecorder(httptest.NewRecorder())
+		if resrec.StatusCode != http.StatusOK {
+			t.Fatalf("want status %d but got %d", http.StatusOK, resrec.StatusCode)
+		}
+	})
+
+	t.Run("should record the first status and the number of bytes written", func(t *testing.T) {
+		resrec := NewResponseStatusRecorder(httptest.NewRecorder())
+		resrec.WriteHeader(http.StatusNotFound)
+		resrec.WriteHeader(http.StatusInternalServerError)
+		resrec.Write([]byte("hello"))
+		resrec.Write([]byte("world"))
+		if resrec.StatusCode != http.StatusNotFound {
+			t.Fatalf("want status %d but got %d", http.StatusNotFound, resrec.StatusCode)
+		}
+		if resrec.BytesWritten != 10 {
+			t.Fatalf("want 10 bytes written but got %d", resrec.BytesWritten)
+		}
+	})
+
+	t.Run("should call BeforeHeader once before the header is sent", func(t *testing.T) {
+		for _, write := range []func(w http.ResponseWriter){
+			func(w http.ResponseWriter) { w.WriteHeader(http.StatusEarlyHints); w.WriteHeader(http.StatusOK) },
+			func(w http.ResponseWriter) { w.Write([]byte("hello")) },
+		} {
+			calls := 0
+			resrec := httptest.NewRecorder()
+			srec := NewResponseStatusRecorder(resrec)
+			srec.BeforeHeader = func() { calls++; srec.Header().Set("X-Test", strconv.Itoa(calls)) }
+			write(srec)
+			srec.Write([]byte("world"))
+			if calls != 1 || resrec.Header().Get("X-Test") != "1" {
+				t.Fatalf("want 1 call before header but got %d calls and header %q", calls, resrec.Header().Get("X-Test"))
+			}
+		}
+	})
+
+	t.Run("should call BeforeHeader and report 101 when the connection is hijacked", func(t *testing.T) {
+		calls := 0
+		srec := NewResponseStatusRecorder(struct {
+			http.ResponseWriter
+			http.Hijacker
+		}{httptest.NewRecorder(), &mockHijacker{}})
+		srec.BeforeHeader = func() { calls++ }
+		if _, _, err := srec.Hijack(); err != nil {
+			t.Fatal(err)
+		}
+		if calls != 1 || srec.StatusCode != http.StatusSwitchingProtocols {
+			t.Fatalf("want 1 call and status %d but got %d calls and status %d", http.StatusSwitchingProtocols, calls, srec.StatusCode)
+		}
+	})
+}
+
+// Mock response writers implementing optional interfaces.
+type (
+	mockFlusher    struct{ flushed bool }
+	mockHijacker   struct{ hijacked bool }
+	mockReaderFrom struct{ read int64 }
+)
+
+func (f *mockFlusher) Flush() { f.flushed = true }
+func (h *mockHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+func (rf *mockReaderFrom) ReadFrom(src io.Reader) (int64, error) {
+	n, err := io.Copy(io.Discard, src)
+	rf.read += n
+	return n, err
+}
+
+func TestResponseStatusRecorderForwarding(t *testing.T) {
+	for i := 0; i < 8; i++ {
+		withFlusher, withHijacker, withReaderFrom := i&1 != 0, i&2 != 0, i&4 != 0
+		description := "flusher=" + strconv.FormatBool(withFlusher) +
+			" hijacker=" + strconv.FormatBool(withHijacker) +
+			" readerfrom=" + strconv.FormatBool(withReaderFrom)
+
+		t.Run(description, func(t *testing.T) {
+			// Build underlying response writer implementing the given interfaces
+			f, h, rf := &mockFlusher{}, &mockHijacker{}, &mockReaderFrom{}
+			w := struct {
+				http.ResponseWriter
+				*mockFlusher
+				*mockHijacker
+				*mockReaderFrom
+			}{httptest.NewRecorder(), f, h, rf}
+			var underlying http.ResponseWriter = w
+			switch {
+			case withFlusher && withHijacker && withReaderFrom:
+			case withFlusher && withHijacker:
+				underlying = struct {
+					http.ResponseWriter
+					http.Flusher
+					http.Hijacker
+				}{w, w, w}
+			case withFlusher && withReaderFrom:
+				underlying = struct {
+					http.ResponseWriter
+					http.Flusher
+					io.ReaderFrom
+				}{w, w, w}
+			case withHijacker && withReaderFrom:
+				underlying = struct {
+					http.ResponseWriter
+					http.Hijacker
+					io.ReaderFrom
+				}{w, w, w}
+			case withFlusher:
+				underlying = struct {
+					http.ResponseWriter
+					http.Flusher
+				}{w, w}
+			case withHijacker:
+				underlying = struct {
+					http.ResponseWriter
+					http.Hijacker
+				}{w, w}
+			case withReaderFrom:
+				underlying = struct {
+					http.ResponseWriter
+					io.ReaderFrom
+				}{w, w}
+			default:
+				underlying = struct{ http.ResponseWriter }{w}
+			}
+
+			srec := NewResponseStatusRecorder(underlying)
+
+			// Check that calls are forwarded when supported and report http.ErrNotSupported otherwise
+			err := http.NewResponseController(srec).Flush()
+			if withFlusher && (err != nil || !f.flushed) {
+				t.Fatalf("want Flush forwarded but got error %v", err)
+			} else if !withFlusher && !errors.Is(err, http.ErrNotSupported) {
+				t.Fatalf("want error %q but got %v", http.ErrNotSupported, err)
+			}
+			_, _, err = srec.Hijack()
+			if withHijacker && (err != nil || !h.hijacked) {
+				t.Fatalf("want Hijack forwarded but got error %v", err)
+			} else if !withHijacker && !errors.Is(err, http.ErrNotSupported) {
+				t.Fatalf("want error %q but got %v", http.ErrNotSupported, err)
+			}
+			srec.ReadFrom(strings.NewReader("hello"))
+			if withReaderFrom && rf.read != 5 {
+				t.Fatalf("want ReadFrom forwarded but got %d bytes read", rf.read)
+			} else if !withReaderFrom && rf.read != 0 {
+				t.Fatal("want ReadFrom not forwarded")
+			}
+			if srec.BytesWritten != 5 {
+				t.Fatalf("want 5 bytes recorded but got %d", srec.BytesWritten)
+			}
+		})
+	}
+}
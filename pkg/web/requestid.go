@@ -0,0 +1,44 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/ejuju/go-utils/pkg/logs"
+	"github.com/ejuju/go-utils/pkg/uid"
+)
+
+// HTTP header used to read and send request IDs.
+var RequestIDHeader = "X-Request-ID"
+
+// Request ID middleware reads the request ID from the request header (or generates a new one),
+// stores it in the request context and sends it back in the response header.
+//
+// Incoming IDs are only used if they are at most 128 characters long and contain only
+// letters, digits, dashes, underscores and dots (to avoid log injection).
+// The ID can be retrieved with RequestID and is included by logs.TextLogger.LogContext.
+func RequestIDMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = uid.MustNewID(16).Hex()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		h.ServeHTTP(w, r.WithContext(logs.WithRequestID(r.Context(), id)))
+	})
+}
+
+// RequestID returns the request ID set by RequestIDMiddleware (or an empty string).
+func RequestID(r *http.Request) string { return logs.RequestIDFromContext(r.Context()) }
+
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		isAlphanumeric := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlphanumeric && c != '-' && c != '_' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		description  string
+		incomingID   string
+		wantSameID   bool
+		wantIDLength int
+	}{
+		{description: "should generate a request ID if none is provided", wantIDLength: 32},
+		{description: "should reuse a valid incoming request ID", incomingID: "abc-123_4.5", wantSameID: true},
+		{description: "should replace an invalid incoming request ID", incomingID: "abc\"\n123", wantIDLength: 32},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			gotID := ""
+			h := Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { gotID = RequestID(r) }), RequestIDMiddleware)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.incomingID != "" {
+				req.Header.Set(RequestIDHeader, test.incomingID)
+			}
+			resrec := httptest.NewRecorder()
+			h.ServeHTTP(resrec, req)
+
+			if test.wantSameID && gotID != test.incomingID {
+				t.Fatalf("want request ID %q but got %q", test.incomingID, gotID)
+			}
+			if !test.wantSameID && len(gotID) != test.wantIDLength {
+				t.Fatalf("want generated request ID of length %d but got %q", test.wantIDLength, gotID)
+			}
+			if resrec.Header().Get(RequestIDHeader) != gotID {
+				t.Fatalf("want response header %q but got %q", gotID, resrec.Header().Get(RequestIDHeader))
+			}
+		})
+	}
+
+	t.Run("should be included in access logs regardless of middleware order", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+		for _, logRequestIDFirst := range []bool{true, false} {
+			logger := &mockLogger{}
+			h := Wrap(Wrap(handler, RequestIDMiddleware), AccessLoggingMiddleware(logger))
+			if logRequestIDFirst {
+				h = Wrap(Wrap(handler, AccessLoggingMiddleware(logger)), RequestIDMiddleware)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(RequestIDHeader, "my-request-id")
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if len(*logger) != 1 || !strings.HasSuffix((*logger)[0], ` request_id="my-request-id"`) {
+				t.Fatalf("want request ID in access log but got %q", *logger)
+			}
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package web
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlockAllRobotsTXT disallows all crawlers from crawling any page.
+const BlockAllRobotsTXT = "User-agent: *\nDisallow: /\n"
+
+// RobotsTXT builds a robots.txt file, for ex:
+//
+//	robots := web.NewRobotsTXT().Sitemap("https://example.com/sitemap.xml")
+//	robots.Group("*").Disallow("/admin/").Allow("/admin/public/")
+//	robots.Group("BadBot").Disallow("/")
+//	routes.Handle(robots.Handler(), web.MatchPath("/robots.txt"))
+type RobotsTXT struct {
+	groups          []*RobotsGroup
+	sitemaps        []string
+	productionHosts []string
+}
+
+// RobotsGroup holds the rules for one or several user agents.
+type RobotsGroup struct {
+	userAgents []string
+	crawlDelay time.Duration
+	rules      [][2]string // directive and path
+}
+
+func NewRobotsTXT() *RobotsTXT { return &RobotsTXT{} }
+
+// Group adds a group of rules for the given user agents ("*" for all crawlers).
+func (rt *RobotsTXT) Group(userAgents ...string) *RobotsGroup {
+	g := &RobotsGroup{userAgents: userAgents}
+	rt.groups = append(rt.groups, g)
+	return g
+}
+
+// Sitemap adds a sitemap reference (absolute URL).
+func (rt *RobotsTXT) Sitemap(urls ...string) *RobotsTXT {
+	rt.sitemaps = append(rt.sitemaps, urls...)
+	return rt
+}
+
+// ProductionHosts sets the hosts where the robots.txt is served as is,
+// requests for other hosts (for ex: staging or preview deployments) get BlockAllRobotsTXT.
+// The port is ignored when comparing hosts.
+func (rt *RobotsTXT) ProductionHosts(hosts ...string) *RobotsTXT {
+	rt.productionHosts = append(rt.productionHosts, hosts...)
+	return rt
+}
+
+func (g *RobotsGroup) Allow(paths ...string) *RobotsGroup    { return g.addRules("Allow", paths) }
+func (g *RobotsGroup) Disallow(paths ...string) *RobotsGroup { return g.addRules("Disallow", paths) }
+
+// CrawlDelay sets the time crawlers should wait between requests (not supported by all crawlers).
+func (g *RobotsGroup) CrawlDelay(d time.Duration) *RobotsGroup {
+	g.crawlDelay = d
+	return g
+}
+
+func (g *RobotsGroup) addRules(directive string, paths []string) *RobotsGroup {
+	for _, p := range paths {
+		g.rules = append(g.rules, [2]string{directive, p})
+	}
+	return g
+}
+
+// String returns the content of the robots.txt file.
+func (rt *RobotsTXT) String() string {
+	lines := []string{}
+	for i, g := range rt.groups {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		for _, ua := range g.userAgents {
+			lines = append(lines, "User-agent: "+sanitizeRobotsValue(ua))
+		}
+		if g.crawlDelay > 0 {
+			lines = append(lines, "Crawl-delay: "+strconv.FormatFloat(g.crawlDelay.Seconds(), 'f', -1, 64))
+		}
+		for _, rule := range g.rules {
+			lines = append(lines, rule[0]+": "+sanitizeRobotsValue(rule[1]))
+		}
+		if len(g.rules) == 0 {
+			lines = append(lines, "Disallow:") // allow everything
+		}
+	}
+	if len(rt.sitemaps) > 0 && len(lines) > 0 {
+		lines = append(lines, "")
+	}
+	for _, u := range rt.sitemaps {
+		lines = append(lines, "Sitemap: "+sanitizeRobotsValue(u))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Removes line breaks so values can't add directives.
+func sanitizeRobotsValue(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(strings.TrimSpace(s))
+}
+
+// Handler serves the robots.txt file (or BlockAllRobotsTXT for non-production hosts).
+// Later changes to the builder are not taken into account by the returned handler.
+func (rt *RobotsTXT) Handler() http.HandlerFunc {
+	serve := serveRobotsTXT(rt.String())
+	blockAll := serveRobotsTXT(BlockAllRobotsTXT)
+	productionHosts := append([]string(nil), rt.productionHosts...)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(productionHosts) > 0 && !isProductionHost(productionHosts, r.Host) {
+			blockAll(w, r)
+			return
+		}
+		serve(w, r)
+	}
+}
+
+func isProductionHost(productionHosts []string, host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for _, prodHost := range productionHosts {
+		if strings.EqualFold(host, prodHost) {
+			return true
+		}
+	}
+	return false
+}
+
+func serveRobotsTXT(content string) http.HandlerFunc {
+	h := ServeRaw([]byte(content))
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		h(w, r)
+	}
+}
@@ -0,0 +1,89 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRobotsTXT(t *testing.T) {
+	t.Run("can generate robots.txt with groups and sitemaps", func(t *testing.T) {
+		robots := NewRobotsTXT().Sitemap("https://example.com/sitemap.xml")
+		robots.Group("*").Disallow("/admin/").Allow("/admin/public/")
+		robots.Group("BadBot", "OtherBot").CrawlDelay(1500 * time.Millisecond).Disallow("/")
+		robots.Group("GoodBot")
+
+		want := "User-agent: *\n" +
+			"Disallow: /admin/\n" +
+			"Allow: /admin/public/\n" +
+			"\n" +
+			"User-agent: BadBot\n" +
+			"User-agent: OtherBot\n" +
+			"Crawl-delay: 1.5\n" +
+			"Disallow: /\n" +
+			"\n" +
+			"User-agent: GoodBot\n" +
+			"Disallow:\n" +
+			"\n" +
+			"Sitemap: https://example.com/sitemap.xml\n"
+		if got := robots.String(); got != want {
+			t.Fatalf("want %q but got %q", want, got)
+		}
+	})
+
+	t.Run("should not allow injecting directives", func(t *testing.T) {
+		robots := NewRobotsTXT()
+		robots.Group("*").Disallow("/a\nAllow: /")
+		if got, want := robots.String(), "User-agent: *\nDisallow: /aAllow: /\n"; got != want {
+			t.Fatalf("want %q but got %q", want, got)
+		}
+	})
+
+	t.Run("can block all crawlers on non-production hosts", func(t *testing.T) {
+		robots := NewRobotsTXT().ProductionHosts("example.com")
+		robots.Group("*").Disallow("/admin/")
+		h := robots.Handler()
+
+		for host, want := range map[string]string{
+			"example.com":         "User-agent: *\nDisallow: /admin/\n",
+			"example.com:8080":    "User-agent: *\nDisallow: /admin/\n",
+			"staging.example.com": BlockAllRobotsTXT,
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/robots.txt", nil)
+			req.Host = host
+			resrec := httptest.NewRecorder()
+			h.ServeHTTP(resrec, req)
+			if got := resrec.Body.String(); got != want {
+				t.Fatalf("%q: want %q but got %q", host, want, got)
+			}
+			if got := resrec.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+				t.Fatalf("want text content type but got %q", got)
+			}
+		}
+	})
+
+	t.Run("should not change the handler when the builder changes", func(t *testing.T) {
+		robots := NewRobotsTXT()
+		robots.Group("*").Disallow("/admin/")
+		h := robots.Handler()
+		robots.ProductionHosts("example.com")
+		robots.Group("*").Disallow("/private/")
+
+		req := httptest.NewRequest(http.MethodGet, "/robots.txt", nil)
+		req.Host = "staging.example.com"
+		resrec := httptest.NewRecorder()
+		h.ServeHTTP(resrec, req)
+		if got, want := resrec.Body.String(), "User-agent: *\nDisallow: /admin/\n"; got != want {
+			t.Fatalf("want %q but got %q", want, got)
+		}
+	})
+
+	t.Run("can serve legacy robots.txt", func(t *testing.T) {
+		resrec := httptest.NewRecorder()
+		ServeRobotsTXT("/private").ServeHTTP(resrec, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+		if got, want := resrec.Body.String(), "User-agent: *\nDisallow: /private\n"; got != want {
+			t.Fatalf("want %q but got %q", want, got)
+		}
+	})
+}
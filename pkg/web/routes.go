@@ -6,31 +6,179 @@ import (
 )
 
 // Routes represents a list of routes.
+//
+// When no route matches a request:
+//   - HEAD requests are handled by the first route matching the same request with the GET method.
+//   - If other routes match the request with another method, OPTIONS requests get a 204 response
+//     and other requests get a 405 response, both with the Allow header set.
+//   - Otherwise the request is handled by the not-found handler (see HandleNotFound).
+//
+// Note that a catch-all route (with no matcher or CatchAll) matches any request,
+// use HandleNotFound instead to get 405 responses.
 type Routes []*Route
 
 func (rhs Routes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = withPathParams(r)
 	rhs.RequestHandler(r).ServeHTTP(w, r)
 }
 
+// Standard HTTP methods checked to find the allowed methods for a request path.
+var standardMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
 func (rhs Routes) RequestHandler(r *http.Request) http.Handler {
+	if rh := rhs.match(r); rh != nil {
+		return rh.handler
+	}
+
+	// Handle HEAD requests with GET handlers
+	if r.Method == http.MethodHead {
+		if rh := rhs.match(withMethod(r, http.MethodGet)); rh != nil {
+			return rh.handler
+		}
+	}
+
+	// Check if the request path is handled for other methods
+	allowed := rhs.allowedMethods(r)
+	if len(allowed) > 0 {
+		if containsString(allowed, http.MethodGet) && !containsString(allowed, http.MethodHead) {
+			allowed = append(allowed, http.MethodHead)
+		}
+		if !containsString(allowed, http.MethodOptions) {
+			allowed = append(allowed, http.MethodOptions)
+		}
+		allowHeader := strings.Join(allowed, ", ")
+		if r.Method == http.MethodOptions {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Allow", allowHeader)
+				w.WriteHeader(http.StatusNoContent)
+			})
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", allowHeader)
+			rhs.fallbackHandler(routeFallbackMethodNotAllowed).ServeHTTP(w, r)
+		})
+	}
+
+	return rhs.fallbackHandler(routeFallbackNotFound)
+}
+
+// Returns the first route (other than fallback routes) matching the request.
+func (rhs Routes) match(r *http.Request) *Route {
+	params, _ := r.Context().Value(ctxKeyPathParams).(*pathParams)
 	for _, rh := range rhs {
+		if rh.fallback != routeFallbackNone {
+			continue
+		}
+		if params != nil {
+			params.values = nil // discard parameters set by previous routes that didn't match
+		}
 		if rh.Match(r) {
-			return rh.handler
+			return rh
+		}
+	}
+	if params != nil {
+		params.values = nil
+	}
+	return nil
+}
+
+// Returns the standard methods for which a route matches the request.
+func (rhs Routes) allowedMethods(r *http.Request) []string {
+	allowed := []string{}
+	for _, method := range standardMethods {
+		if rhs.match(withMethod(r, method)) != nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// Reports whether a fallback handler of the given kind was registered.
+func (rhs Routes) hasFallback(kind routeFallback) bool {
+	for _, rh := range rhs {
+		if rh.fallback == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the last registered fallback handler of the given kind or the default one.
+func (rhs Routes) fallbackHandler(kind routeFallback) http.Handler {
+	for i := len(rhs) - 1; i >= 0; i-- {
+		if rhs[i].fallback == kind {
+			return rhs[i].handler
+		}
+	}
+	if kind == routeFallbackMethodNotAllowed {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		})
+	}
+	return http.NotFoundHandler()
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
 		}
 	}
-	panic("no match found")
+	return false
+}
+
+// Returns a shallow copy of the request with the given method.
+func withMethod(r *http.Request, method string) *http.Request {
+	out := r.WithContext(r.Context())
+	out.Method = method
+	return out
+}
+
+// Handle registers a handler for requests matching all the given matchers.
+// It returns the route so it can be configured further (for ex: with AddToSitemap).
+func (rhs *Routes) Handle(h http.Handler, matchers ...RequestMatcher) *Route {
+	route := &Route{handler: h, matchers: matchers}
+	*rhs = append(*rhs, route)
+	return route
+}
+
+// Sets the handler used when no route matches the request path (defaults to http.NotFoundHandler).
+func (rhs *Routes) HandleNotFound(h http.Handler) {
+	*rhs = append(*rhs, &Route{handler: h, fallback: routeFallbackNotFound})
 }
 
-func (rhs *Routes) Handle(h http.Handler, matchers ...RequestMatcher) {
-	*rhs = append(*rhs, &Route{handler: h, matchers: matchers})
+// Sets the handler used when routes match the request path but not its method.
+// The Allow header is set before the handler is called.
+func (rhs *Routes) HandleMethodNotAllowed(h http.Handler) {
+	*rhs = append(*rhs, &Route{handler: h, fallback: routeFallbackMethodNotAllowed})
 }
 
 // Route represents a HTTP handler with request matchers.
 type Route struct {
 	handler  http.Handler
 	matchers []RequestMatcher
+	fallback routeFallback // Fallback routes are only used when no other route matches
+	sitemap  []SitemapURL  // Entries added to sitemaps generated from routes
 }
 
+type routeFallback int
+
+const (
+	routeFallbackNone routeFallback = iota
+	routeFallbackNotFound
+	routeFallbackMethodNotAllowed
+)
+
 // If all matchers yield true, this function returns true.
 // If there are no matchers provided, true is also returned.
 func (rh *Route) Match(r *http.Request) bool {
@@ -82,8 +230,8 @@ func MatchMethodOptions(r *http.Request) bool { return r.Method == http.MethodOp
 func MatchMethodTrace(r *http.Request) bool   { return r.Method == http.MethodTrace }
 
 // Defined for readability purposes, to make it explicit that the handlers intents to catch all requests.
-// Used for handling 404s.
 // Equivalent to not using any RequestMatcher in routes.Handle, as this will match any request.
+// Prefer Routes.HandleNotFound for handling 404s, as a catch-all route prevents 405 responses.
 func CatchAll(_ *http.Request) bool { return true }
 
 // Matches a certain host. Ignores port if present.
@@ -52,6 +52,221 @@ func TestRoutes(t *testing.T) {
 	})
 }
 
+func TestRoutesFallbacks(t *testing.T) {
+	newRoutes := func() Routes {
+		routes := Routes{}
+		routes.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Handler", "get")
+			w.Write([]byte("hello"))
+		}), MatchPath("/hello"), MatchMethodGet)
+		routes.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Handler", "post")
+		}), MatchPath("/hello"), MatchMethodPost)
+		return routes
+	}
+
+	tests := []struct {
+		description string
+		method      string
+		path        string
+		wantStatus  int
+		wantAllow   string
+		wantHandler string
+	}{
+		{
+			description: "should respond with 404 when no path matches",
+			method:      http.MethodGet,
+			path:        "/unknown",
+			wantStatus:  http.StatusNotFound,
+		},
+		{
+			description: "should respond with 405 when the path matches but not the method",
+			method:      http.MethodDelete,
+			path:        "/hello",
+			wantStatus:  http.StatusMethodNotAllowed,
+			wantAllow:   "GET, POST, HEAD, OPTIONS",
+		},
+		{
+			description: "should handle HEAD requests with the GET handler",
+			method:      http.MethodHead,
+			path:        "/hello",
+			wantStatus:  http.StatusOK,
+			wantHandler: "get",
+		},
+		{
+			description: "should answer OPTIONS requests",
+			method:      http.MethodOptions,
+			path:        "/hello",
+			wantStatus:  http.StatusNoContent,
+			wantAllow:   "GET, POST, HEAD, OPTIONS",
+		},
+		{
+			description: "should answer OPTIONS requests with 404 when no path matches",
+			method:      http.MethodOptions,
+			path:        "/unknown",
+			wantStatus:  http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			resrec := httptest.NewRecorder()
+			newRoutes().ServeHTTP(resrec, httptest.NewRequest(test.method, test.path, nil))
+			if resrec.Code != test.wantStatus {
+				t.Fatalf("want status %d but got %d", test.wantStatus, resrec.Code)
+			}
+			if got := resrec.Header().Get("Allow"); got != test.wantAllow {
+				t.Fatalf("want Allow header %q but got %q", test.wantAllow, got)
+			}
+			if got := resrec.Header().Get("X-Handler"); got != test.wantHandler {
+				t.Fatalf("want handler %q but got %q", test.wantHandler, got)
+			}
+		})
+	}
+
+	t.Run("can use custom 404 and 405 handlers", func(t *testing.T) {
+		routes := newRoutes()
+		routes.HandleNotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) }))
+		routes.HandleMethodNotAllowed(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusConflict) }))
+
+		resrec := httptest.NewRecorder()
+		routes.ServeHTTP(resrec, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+		if resrec.Code != http.StatusTeapot {
+			t.Fatalf("404 handler: want status %d but got %d", http.StatusTeapot, resrec.Code)
+		}
+
+		resrec = httptest.NewRecorder()
+		routes.ServeHTTP(resrec, httptest.NewRequest(http.MethodPut, "/hello", nil))
+		if resrec.Code != http.StatusConflict {
+			t.Fatalf("405 handler: want status %d but got %d", http.StatusConflict, resrec.Code)
+		}
+		if resrec.Header().Get("Allow") == "" {
+			t.Fatal("405 handler: want Allow header to be set")
+		}
+	})
+}
+
+func TestGroup(t *testing.T) {
+	t.Run("can route requests to group routes with scoped middleware", func(t *testing.T) {
+		calls := []string{}
+		logMiddleware := func(name string) func(http.Handler) http.Handler {
+			return func(h http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls = append(calls, name)
+					h.ServeHTTP(w, r)
+				})
+			}
+		}
+		handler := func(name string) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name+" "+r.URL.Path+" "+PathParam(r, "id"))
+			})
+		}
+
+		routes := Routes{}
+		routes.Handle(handler("home"), MatchPath("/"))
+		admin := NewGroup("/admin").Use(logMiddleware("auth"), logMiddleware("log"))
+		admin.Handle(handler("dashboard"), MatchPath("/"))
+		admin.Handle(handler("user"), MatchPattern("/users/{id}"))
+		admin.Group("/api").Use(logMiddleware("api")).Handle(handler("api"), MatchPath("/status"))
+		routes.Mount(admin)
+
+		tests := []struct {
+			path      string
+			wantCalls []string
+		}{
+			{path: "/", wantCalls: []string{"home / "}},
+			{path: "/admin", wantCalls: []string{"auth", "log", "dashboard / "}},
+			{path: "/admin/users/42", wantCalls: []string{"auth", "log", "user /users/42 42"}},
+			{path: "/admin/api/status", wantCalls: []string{"auth", "log", "api", "api /status "}},
+			{path: "/administrator", wantCalls: []string{}},
+		}
+		for _, test := range tests {
+			calls = []string{}
+			routes.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, test.path, nil))
+			if len(calls) != len(test.wantCalls) {
+				t.Fatalf("path %q: want calls %q but got %q", test.path, test.wantCalls, calls)
+			}
+			for i := range calls {
+				if calls[i] != test.wantCalls[i] {
+					t.Fatalf("path %q: want calls %q but got %q", test.path, test.wantCalls, calls)
+				}
+			}
+		}
+	})
+
+	t.Run("should only match requests accepted by group matchers", func(t *testing.T) {
+		routes := Routes{}
+		group := NewGroup("/api", MatchHeader("Authorization", "secret"))
+		group.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+		routes.Mount(group)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/anything", nil)
+		resrec := httptest.NewRecorder()
+		routes.ServeHTTP(resrec, req)
+		if resrec.Code != http.StatusNotFound {
+			t.Fatalf("want status %d but got %d", http.StatusNotFound, resrec.Code)
+		}
+
+		req.Header.Set("Authorization", "secret")
+		resrec = httptest.NewRecorder()
+		routes.ServeHTTP(resrec, req)
+		if resrec.Code != http.StatusOK {
+			t.Fatalf("want status %d but got %d", http.StatusOK, resrec.Code)
+		}
+	})
+
+	t.Run("should leave requests not handled by group routes to the parent routes", func(t *testing.T) {
+		authCalled := false
+		auth := func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authCalled = true
+				w.WriteHeader(http.StatusUnauthorized)
+			})
+		}
+		status := func(code int) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(code) })
+		}
+
+		routes := Routes{}
+		group := NewGroup("/api").Use(auth)
+		group.Handle(status(http.StatusOK), MatchMethodGet, MatchPath("/private"))
+		routes.Mount(group)
+		routes.Handle(status(http.StatusOK), MatchPath("/api/public"))
+		routes.HandleNotFound(status(http.StatusTeapot))
+
+		tests := []struct {
+			method         string
+			path           string
+			wantStatus     int
+			wantAuthCalled bool
+		}{
+			{method: http.MethodGet, path: "/api/private", wantStatus: http.StatusUnauthorized, wantAuthCalled: true},
+			{method: http.MethodGet, path: "/api/public", wantStatus: http.StatusOK},
+			{method: http.MethodGet, path: "/api/unknown", wantStatus: http.StatusTeapot},
+			{method: http.MethodPost, path: "/api/private", wantStatus: http.StatusMethodNotAllowed},
+		}
+		for _, test := range tests {
+			authCalled = false
+			resrec := httptest.NewRecorder()
+			routes.ServeHTTP(resrec, httptest.NewRequest(test.method, test.path, nil))
+			if resrec.Code != test.wantStatus {
+				t.Fatalf("%s %s: want status %d but got %d", test.method, test.path, test.wantStatus, resrec.Code)
+			}
+			if authCalled != test.wantAuthCalled {
+				t.Fatalf("%s %s: want auth middleware called %v but got %v", test.method, test.path, test.wantAuthCalled, authCalled)
+			}
+		}
+
+		group.HandleNotFound(status(http.StatusGone))
+		resrec := httptest.NewRecorder()
+		routes.ServeHTTP(resrec, httptest.NewRequest(http.MethodGet, "/api/unknown", nil))
+		if resrec.Code != http.StatusUnauthorized {
+			t.Fatalf("with group 404 handler: want status %d but got %d", http.StatusUnauthorized, resrec.Code)
+		}
+	})
+}
+
 func TestRequestMatchers(t *testing.T) {
 	t.Run("can match sub-domain", func(t *testing.T) {
 		tests := []struct {
@@ -132,3 +347,128 @@ func TestRequestMatchers(t *testing.T) {
 		}
 	})
 }
+
+func TestPatternMatching(t *testing.T) {
+	t.Run("can match path patterns", func(t *testing.T) {
+		tests := []struct {
+			pattern    string
+			requestURL string
+			wantMatch  bool
+			wantParams map[string]string
+		}{
+			{
+				pattern:    "/",
+				requestURL: "/",
+				wantMatch:  true,
+			},
+			{
+				pattern:    "/posts",
+				requestURL: "/posts/",
+				wantMatch:  true, // trailing slash is optional
+			},
+			{
+				pattern:    "/posts/{id}",
+				requestURL: "/posts/123",
+				wantMatch:  true,
+				wantParams: map[string]string{"id": "123"},
+			},
+			{
+				pattern:    "/posts/{id}",
+				requestURL: "/posts/123/",
+				wantMatch:  true,
+				wantParams: map[string]string{"id": "123"},
+			},
+			{
+				pattern:    "/posts/{id}",
+				requestURL: "/posts/",
+				wantMatch:  false, // parameters can't be empty
+			},
+			{
+				pattern:    "/posts/{id}",
+				requestURL: "/posts/123/comments",
+				wantMatch:  false,
+			},
+			{
+				pattern:    "/posts/{id}",
+				requestURL: "/posts/a%2Fb",
+				wantMatch:  true,
+				wantParams: map[string]string{"id": "a/b"}, // escaped slashes are not segment separators
+			},
+			{
+				pattern:    "/users/{user}/posts/{post}",
+				requestURL: "/users/alice/posts/1",
+				wantMatch:  true,
+				wantParams: map[string]string{"user": "alice", "post": "1"},
+			},
+			{
+				pattern:    "/users/*/posts",
+				requestURL: "/users/bob/posts",
+				wantMatch:  true,
+			},
+			{
+				pattern:    "/files/{path...}",
+				requestURL: "/files/css/main.css",
+				wantMatch:  true,
+				wantParams: map[string]string{"path": "css/main.css"},
+			},
+			{
+				pattern:    "/files/{path...}",
+				requestURL: "/files",
+				wantMatch:  true,
+				wantParams: map[string]string{"path": ""},
+			},
+			{
+				pattern:    "/files/{path...}",
+				requestURL: "/other/main.css",
+				wantMatch:  false,
+			},
+		}
+
+		for _, test := range tests {
+			gotParams := map[string]string{}
+			routes := Routes{}
+			routes.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k := range test.wantParams {
+					gotParams[k] = PathParam(r, k)
+				}
+				w.WriteHeader(http.StatusOK)
+			}), MatchPattern(test.pattern))
+			routes.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, test.requestURL, nil)
+			resrec := httptest.NewRecorder()
+			routes.ServeHTTP(resrec, req)
+			gotMatch := resrec.Code == http.StatusOK
+			if gotMatch != test.wantMatch {
+				t.Fatalf("pattern %q and URL %q: want %v but got %v", test.pattern, test.requestURL, test.wantMatch, gotMatch)
+			}
+			for k, want := range test.wantParams {
+				if gotParams[k] != want {
+					t.Fatalf("pattern %q and URL %q: want param %q to be %q but got %q", test.pattern, test.requestURL, k, want, gotParams[k])
+				}
+			}
+		}
+	})
+
+	t.Run("should not leak parameters from routes that did not match", func(t *testing.T) {
+		gotID := "not called"
+		routes := Routes{}
+		routes.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), MatchPattern("/posts/{id}"), MatchMethodPost)
+		routes.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { gotID = PathParam(r, "id") }))
+
+		routes.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts/1", nil))
+		if gotID != "" {
+			t.Fatalf("want empty param but got %q", gotID)
+		}
+	})
+
+	t.Run("should fail to parse invalid patterns", func(t *testing.T) {
+		for _, pattern := range []string{"posts", "/posts/{}", "/{path...}/edit", "/{id}/{id}", "/posts/{id"} {
+			if _, err := ParsePathPattern(pattern); err == nil {
+				t.Fatalf("pattern %q: want error but got nil", pattern)
+			}
+		}
+	})
+}
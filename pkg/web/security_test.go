@@ -0,0 +1,67 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	t.Run("can set headers with a new nonce for each request", func(t *testing.T) {
+		var nonce string
+		h := SecurityHeadersMiddleware(DefaultSecurityHeadersConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nonce = CSPNonceFromRequest(r)
+		}))
+
+		nonces := map[string]bool{}
+		for i := 0; i < 2; i++ {
+			resrec := httptest.NewRecorder()
+			h.ServeHTTP(resrec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if nonce == "" || nonces[nonce] {
+				t.Fatalf("want new nonce but got %q", nonce)
+			}
+			nonces[nonce] = true
+			csp := resrec.Header().Get("Content-Security-Policy")
+			if !strings.Contains(csp, "script-src 'self' 'nonce-"+nonce+"'") {
+				t.Fatalf("want nonce %q in policy but got %q", nonce, csp)
+			}
+			for k, want := range map[string]string{
+				"X-Frame-Options":        "DENY",
+				"Referrer-Policy":        "strict-origin-when-cross-origin",
+				"X-Content-Type-Options": "nosniff",
+			} {
+				if got := resrec.Header().Get(k); got != want {
+					t.Fatalf("%s: want %q but got %q", k, want, got)
+				}
+			}
+		}
+	})
+
+	t.Run("can send a report-only policy without nonce", func(t *testing.T) {
+		config := &SecurityHeadersConfig{
+			CSP:           NewCSP().Set(CSPDefaultSrc, CSPSelf).Set(CSPImgSrc, CSPSelf, "https://cdn.example.com").Set(CSPDefaultSrc, CSPNone),
+			CSPReportOnly: true,
+		}
+		var nonce string
+		h := SecurityHeadersMiddleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nonce = CSPNonceFromRequest(r)
+		}))
+		resrec := httptest.NewRecorder()
+		h.ServeHTTP(resrec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		want := "default-src 'none'; img-src 'self' https://cdn.example.com"
+		if got := resrec.Header().Get("Content-Security-Policy-Report-Only"); got != want {
+			t.Fatalf("want %q but got %q", want, got)
+		}
+		if got := resrec.Header().Get("Content-Security-Policy"); got != "" {
+			t.Fatalf("want no enforced policy but got %q", got)
+		}
+		if got := resrec.Header().Get("X-Frame-Options"); got != "" {
+			t.Fatalf("want no X-Frame-Options but got %q", got)
+		}
+		if nonce != "" {
+			t.Fatalf("want no nonce but got %q", nonce)
+		}
+	})
+}
@@ -2,42 +2,48 @@ package web
 
 import "net/http"
 
-// Generate sitemap XML
+// Generate sitemap XML for the given paths on the HTTPS host (with priority 1).
+// All paths are listed in a single file, use Sitemap for more options.
 func SitemapXML(host string, routes ...string) string {
-	out := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"
-	out += "<urlset xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\">\n"
+	set := &xmlURLSet{XMLNS: sitemapXMLNS}
 	for _, r := range routes {
-		out += "\t<url>\n"
-		out += "\t\t<loc>" + "https://" + host + r + "</loc>\n"
-		out += "\t\t<priority>1</priority>\n"
-		out += "\t</url>\n"
+		set.URLs = append(set.URLs, xmlURL{Loc: "https://" + host + r, Priority: "1.0"})
 	}
-	out += "</urlset>\n"
-	return out
+	raw, _ := (&Sitemap{}).encode(set) // encoding strings can't fail (invalid characters are replaced)
+	return string(raw)
 }
 
 func ServeSitemapXML(host string, routes ...string) http.HandlerFunc {
-	return ServeRaw([]byte(SitemapXML(host, routes...)))
+	h := ServeRaw([]byte(SitemapXML(host, routes...)))
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		h(w, r)
+	}
 }
 
-// Generate robots.txt with disallowed routes
-func DisallowedRobotsTXT(routes []string) string {
-	out := "User-agent: *\n"
-	for _, r := range routes {
-		out += "Disallow: " + r + "\n"
-	}
-	return out
+// Generate robots.txt with disallowed routes for all user agents.
+// Use RobotsTXT for more options.
+func DisallowedRobotsTXT(routes []string) string { return NewDisallowedRobotsTXT(routes...).String() }
+
+// NewDisallowedRobotsTXT returns a robots.txt builder disallowing the given routes for all user agents.
+func NewDisallowedRobotsTXT(routes ...string) *RobotsTXT {
+	robots := NewRobotsTXT()
+	robots.Group("*").Disallow(routes...)
+	return robots
 }
 
+// ServeRobotsTXT serves a robots.txt file disallowing the given routes for all user agents.
+// Use RobotsTXT.Handler for more options.
 func ServeRobotsTXT(disallowedRoutes ...string) http.HandlerFunc {
-	return ServeRaw([]byte(DisallowedRobotsTXT(disallowedRoutes)))
+	return NewDisallowedRobotsTXT(disallowedRoutes...).Handler()
 }
 
 // Website JSON+LD schema
+//
+// Deprecated: use WebSite and JSONLDScript.
 type JSONLDWebsiteSchema struct {
 	Context string `json:"@context"`
-	Type    struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"@type"`
+	Type    string `json:"@type"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
 }
@@ -0,0 +1,209 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+func NewServerWithDefaults(h http.Handler, port int) *http.Server {
+	out := &http.Server{
+		Addr:              ":" + strconv.Itoa(port),
+		Handler:           h,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       30 * time.Second,
+		ReadHeaderTimeout: 30 * time.Second,
+		MaxHeaderBytes:    8000,
+	}
+	return out
+}
+
+// Listens for incoming connections and
+// await interrupt signal (or server error) for graceful shutdown.
+// The onShutdown callback (optional) is called before the server is shut down.
+func RunServer(s *http.Server, onShutown func() error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	config := &RunConfig{Servers: []*http.Server{s}, DrainTimeout: time.Second}
+	if onShutown != nil {
+		config.PreShutdownHooks = append(config.PreShutdownHooks, func(context.Context) error { return onShutown() })
+	}
+	return Run(ctx, config)
+}
+
+// ShutdownHook is called when shutting down.
+type ShutdownHook func(ctx context.Context) error
+
+// RunConfig configures Run.
+type RunConfig struct {
+	Servers          []*http.Server // Servers are started with ServeTLS if they have a TLS config, Serve otherwise
+	Readiness        *Readiness     // Optional, set to ready once servers are listening and to not ready before draining
+	DrainDelay       time.Duration  // Optional, time to wait after flipping readiness before draining (so load balancers can notice)
+	DrainTimeout     time.Duration  // Max time to wait for active connections to finish (default: 10s), also used as timeout for hooks
+	PreShutdownHooks []ShutdownHook // Called in order when shutdown starts, before servers are drained
+	ShutdownHooks    []ShutdownHook // Called in order after servers have been drained
+}
+
+// Run starts the given servers and gracefully shuts them down when the context is done
+// or when one of the servers fails.
+//
+// Servers start listening before Run returns or reports readiness,
+// so a listen error (for ex: address already in use) is returned right away (after calling hooks).
+//
+// Shutdown happens in the following order:
+//  1. Pre-shutdown hooks are called in order
+//  2. Readiness is set to not ready and Run waits for DrainDelay
+//  3. All servers are shut down (concurrently) and active connections are drained
+//  4. Shutdown hooks are called in order (all hooks are called even if one fails)
+//
+// Errors (listen, server, shutdown and hook errors) are joined and returned.
+func Run(ctx context.Context, config *RunConfig) error {
+	drainTimeout := config.DrainTimeout
+	if drainTimeout <= 0 {
+		drainTimeout = 10 * time.Second
+	}
+
+	// Listen synchronously so servers accept connections as soon as readiness is reported
+	var errs []error
+	listeners := []net.Listener{}
+	for _, s := range config.Servers {
+		l, err := net.Listen("tcp", serverListenAddr(s))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("listen %q: %w", s.Addr, err))
+			break
+		}
+		listeners = append(listeners, l)
+	}
+
+	// Start serving in separate goroutines and await context cancellation (or server error) for graceful shutdown
+	errc := make(chan error, len(config.Servers))
+	serving := sync.WaitGroup{}
+	if len(errs) > 0 {
+		for _, l := range listeners {
+			l.Close()
+		}
+	} else {
+		for i, s := range config.Servers {
+			serving.Add(1)
+			go func(s *http.Server, l net.Listener) {
+				defer serving.Done()
+				var err error
+				if serverUsesTLS(s) {
+					err = s.ServeTLS(l, "", "")
+				} else {
+					err = s.Serve(l)
+				}
+				if !errors.Is(err, http.ErrServerClosed) {
+					errc <- fmt.Errorf("server %q: %w", s.Addr, err)
+				}
+			}(s, listeners[i])
+		}
+		if config.Readiness != nil {
+			config.Readiness.SetReady(true)
+		}
+		select {
+		case <-ctx.Done():
+		case err := <-errc:
+			errs = append(errs, err)
+		}
+	}
+
+	// Call pre-shutdown hooks
+	preHooksCtx, cancelPreHooks := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancelPreHooks()
+	errs = append(errs, callShutdownHooks(preHooksCtx, "pre-shutdown hook", config.PreShutdownHooks)...)
+
+	// Flip readiness and wait for load balancers to stop sending traffic
+	if config.Readiness != nil {
+		config.Readiness.SetReady(false)
+		time.Sleep(config.DrainDelay)
+	}
+
+	// Drain servers
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for _, s := range config.Servers {
+		wg.Add(1)
+		go func(s *http.Server) {
+			defer wg.Done()
+			if err := s.Shutdown(shutdownCtx); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("shutdown server %q: %w", s.Addr, err))
+				mu.Unlock()
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	// Collect errors of other servers
+	serving.Wait()
+	close(errc)
+	for err := range errc {
+		errs = append(errs, err)
+	}
+
+	// Call shutdown hooks
+	postHooksCtx, cancelPostHooks := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancelPostHooks()
+	errs = append(errs, callShutdownHooks(postHooksCtx, "shutdown hook", config.ShutdownHooks)...)
+
+	return errors.Join(errs...)
+}
+
+// Calls all hooks in order and returns their errors.
+func callShutdownHooks(ctx context.Context, name string, hooks []ShutdownHook) []error {
+	var errs []error
+	for i, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s %d: %w", name, i, err))
+		}
+	}
+	return errs
+}
+
+// Reports whether the server should be served with TLS (using the certificates of its TLS config).
+func serverUsesTLS(s *http.Server) bool {
+	return s.TLSConfig != nil && (len(s.TLSConfig.Certificates) > 0 || s.TLSConfig.GetCertificate != nil)
+}
+
+// Returns the server address, defaulting to ":http" or ":https" (like ListenAndServe and ListenAndServeTLS).
+func serverListenAddr(s *http.Server) string {
+	switch {
+	case s.Addr != "":
+		return s.Addr
+	case serverUsesTLS(s):
+		return ":https"
+	}
+	return ":http"
+}
+
+// Readiness reports whether the app is ready to receive traffic.
+// Use Handler to expose it to load balancers or orchestrators.
+type Readiness struct{ ready atomic.Bool }
+
+func (rd *Readiness) SetReady(ready bool) { rd.ready.Store(ready) }
+func (rd *Readiness) IsReady() bool       { return rd.ready.Load() }
+
+// Handler responds with 200 OK when ready and 503 Service Unavailable otherwise.
+func (rd *Readiness) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !rd.IsReady() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ready"))
+	}
+}
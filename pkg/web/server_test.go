@@ -0,0 +1,109 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Returns an address with a port that is free at the time of the call.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestRun(t *testing.T) {
+	t.Run("can serve and gracefully shut down several servers", func(t *testing.T) {
+		readiness := &Readiness{}
+		app := &http.Server{Addr: freeAddr(t), Handler: readiness.Handler()}
+		admin := &http.Server{Addr: freeAddr(t), Handler: readiness.Handler()}
+		calls := []string{}
+		wantErr := errors.New("hook failed")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errc := make(chan error, 1)
+		go func() {
+			errc <- Run(ctx, &RunConfig{
+				Servers:   []*http.Server{app, admin},
+				Readiness: readiness,
+				PreShutdownHooks: []ShutdownHook{
+					func(ctx context.Context) error {
+						res, err := http.Get("http://" + app.Addr)
+						if err == nil {
+							res.Body.Close()
+							calls = append(calls, "pre")
+						}
+						return err
+					},
+				},
+				ShutdownHooks: []ShutdownHook{
+					func(ctx context.Context) error { calls = append(calls, "first"); return wantErr },
+					func(ctx context.Context) error { calls = append(calls, "second"); return nil },
+				},
+			})
+		}()
+
+		// Wait for readiness, servers should then accept connections
+		for i := 0; i < 100 && !readiness.IsReady(); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		for _, s := range []*http.Server{app, admin} {
+			res, err := http.Get("http://" + s.Addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("server %q: want status %d but got %d", s.Addr, http.StatusOK, res.StatusCode)
+			}
+		}
+
+		cancel()
+		err := <-errc
+		if !errors.Is(err, wantErr) {
+			t.Fatalf("want hook error but got %v", err)
+		}
+		if len(calls) != 3 || calls[0] != "pre" || calls[1] != "first" || calls[2] != "second" {
+			t.Fatalf("want hooks called in order but got %q", calls)
+		}
+		if readiness.IsReady() {
+			t.Fatal("want not ready after shutdown")
+		}
+		if _, err := http.Get("http://" + app.Addr); err == nil {
+			t.Fatal("want server closed after shutdown")
+		}
+	})
+
+	t.Run("should return server errors", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+
+		readiness := &Readiness{}
+		hookCalled := false
+		err = Run(context.Background(), &RunConfig{
+			Servers:       []*http.Server{{Addr: l.Addr().String()}}, // address already in use
+			Readiness:     readiness,
+			ShutdownHooks: []ShutdownHook{func(ctx context.Context) error { hookCalled = true; return nil }},
+		})
+		if err == nil {
+			t.Fatal("want error but got nil")
+		}
+		if !hookCalled {
+			t.Fatal("want shutdown hook called")
+		}
+		if readiness.IsReady() {
+			t.Fatal("want not ready")
+		}
+	})
+}
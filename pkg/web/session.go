@@ -0,0 +1,397 @@
+package web
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ejuju/go-utils/pkg/kv"
+	"github.com/ejuju/go-utils/pkg/uid"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionTooLarge = errors.New("session too large")
+)
+
+// SessionData holds the data persisted by a SessionStore.
+type SessionData struct {
+	ID        string            `json:"id"`
+	Values    map[string]string `json:"values,omitempty"`
+	Flashes   []string          `json:"flashes,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// SessionStore persists sessions.
+type SessionStore interface {
+	// Load returns the session identified by the cookie value or ErrSessionNotFound.
+	Load(cookieValue string) (*SessionData, error)
+	// Save persists the session and returns the cookie value identifying it.
+	Save(data *SessionData) (cookieValue string, err error)
+	// Delete removes the session with the given ID.
+	Delete(id string) error
+}
+
+// Session holds the data of the current visitor, use GetSession to get it in handlers.
+// Changes are saved by SessionMiddleware when the response header is sent.
+type Session struct {
+	data       SessionData
+	isNew      bool
+	modified   bool
+	destroyed  bool
+	previousID string
+}
+
+func (s *Session) ID() string           { return s.data.ID }
+func (s *Session) ExpiresAt() time.Time { return s.data.ExpiresAt }
+
+// IsNew reports whether the session was created during this request.
+func (s *Session) IsNew() bool { return s.isNew }
+
+func (s *Session) Get(k string) string { return s.data.Values[k] }
+
+func (s *Session) Set(k, v string) {
+	if s.data.Values == nil {
+		s.data.Values = map[string]string{}
+	}
+	s.data.Values[k] = v
+	s.modified = true
+}
+
+func (s *Session) Delete(k string) {
+	if _, ok := s.data.Values[k]; ok {
+		delete(s.data.Values, k)
+		s.modified = true
+	}
+}
+
+// AddFlash adds a message that will be returned by Flashes (for ex: on the next page).
+func (s *Session) AddFlash(msg string) {
+	s.data.Flashes = append(s.data.Flashes, msg)
+	s.modified = true
+}
+
+// Flashes returns and removes flash messages.
+func (s *Session) Flashes() []string {
+	flashes := s.data.Flashes
+	if len(flashes) > 0 {
+		s.data.Flashes = nil
+		s.modified = true
+	}
+	return flashes
+}
+
+// Rotate gives a new ID (and expiry) to the session and deletes the previous one, values are kept.
+// Call it when the user logs in (or their privileges change) to prevent session fixation.
+func (s *Session) Rotate() {
+	if s.previousID == "" && !s.isNew {
+		s.previousID = s.data.ID
+	}
+	s.data.ID = newSessionID()
+	s.data.ExpiresAt = time.Time{} // reset by the middleware
+	s.modified = true
+}
+
+// Destroy clears the session and deletes it and its cookie (for ex: when the user logs out).
+func (s *Session) Destroy() {
+	s.data.Values, s.data.Flashes = nil, nil
+	s.destroyed = true
+}
+
+func newSessionID() string { return uid.MustNewID(32).Hex() }
+
+// Reports whether s has the format of IDs returned by newSessionID (64 hexadecimal characters).
+func isSessionID(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// SessionConfig configures SessionMiddleware.
+type SessionConfig struct {
+	Store      SessionStore                     // Required
+	CookieName string                           // Default: "session"
+	MaxAge     time.Duration                    // Default: 7 days
+	Secure     bool                             // Always set the Secure cookie attribute (it is set for HTTPS requests anyway)
+	OnError    func(err error, r *http.Request) // Optional, called when the store fails
+}
+
+// Session middleware loads the session before calling the handler
+// and saves it (if modified) when the response header is sent.
+// New sessions are only saved (and the cookie only sent) once they hold data.
+// Sessions expire MaxAge after being created or rotated.
+func SessionMiddleware(config *SessionConfig) func(http.Handler) http.Handler {
+	if config.Store == nil {
+		panic(errors.New("missing session store"))
+	}
+	cookieName := stringOrDefault(config.CookieName, "session")
+	maxAge := config.MaxAge
+	if maxAge <= 0 {
+		maxAge = 7 * 24 * time.Hour
+	}
+	onError := func(err error, r *http.Request) {
+		if config.OnError != nil {
+			config.OnError(err, r)
+		}
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session := loadSession(r, config.Store, cookieName, onError)
+			r = r.WithContext(context.WithValue(r.Context(), ctxKeySession, session))
+
+			save := func() {
+				cookie := &http.Cookie{
+					Name:     cookieName,
+					Path:     "/",
+					Secure:   config.Secure || r.TLS != nil,
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				}
+				if session.destroyed {
+					for _, id := range []string{session.previousID, session.data.ID} {
+						if id == "" {
+							continue
+						}
+						if err := config.Store.Delete(id); err != nil {
+							onError(fmt.Errorf("delete session: %w", err), r)
+						}
+					}
+					if !session.isNew {
+						cookie.MaxAge = -1
+						http.SetCookie(w, cookie)
+					}
+					return
+				}
+				if !session.modified {
+					return
+				}
+				if session.previousID != "" {
+					if err := config.Store.Delete(session.previousID); err != nil {
+						onError(fmt.Errorf("delete previous session: %w", err), r)
+					}
+				}
+				if session.data.ExpiresAt.IsZero() {
+					session.data.ExpiresAt = time.Now().Add(maxAge)
+				}
+				v, err := config.Store.Save(&session.data)
+				if err != nil {
+					onError(fmt.Errorf("save session: %w", err), r)
+					return
+				}
+				cookie.Value = v
+				cookie.Expires = session.data.ExpiresAt
+				cookie.MaxAge = int(time.Until(session.data.ExpiresAt).Seconds())
+				http.SetCookie(w, cookie)
+			}
+
+			srec := NewResponseStatusRecorder(w)
+			srec.BeforeHeader = save
+			h.ServeHTTP(srec, r)
+			if !srec.WroteHeader() {
+				save()
+			}
+		})
+	}
+}
+
+// Loads the session from the cookie or returns a new one.
+func loadSession(r *http.Request, store SessionStore, cookieName string, onError func(error, *http.Request)) *Session {
+	if cookie, err := r.Cookie(cookieName); err == nil {
+		data, err := store.Load(cookie.Value)
+		if err == nil && time.Now().Before(data.ExpiresAt) {
+			return &Session{data: *data}
+		} else if err == nil {
+			if err := store.Delete(data.ID); err != nil {
+				onError(fmt.Errorf("delete expired session: %w", err), r)
+			}
+		} else if !errors.Is(err, ErrSessionNotFound) {
+			onError(fmt.Errorf("load session: %w", err), r)
+		}
+	}
+	return &Session{data: SessionData{ID: newSessionID()}, isNew: true}
+}
+
+// GetSession returns the session loaded by SessionMiddleware (or nil).
+func GetSession(r *http.Request) *Session {
+	session, _ := r.Context().Value(ctxKeySession).(*Session)
+	return session
+}
+
+// CookieSessionStore stores sessions in the cookie itself.
+// Cookies are signed with HMAC-SHA256 and optionally encrypted with AES-GCM.
+// Sessions can't be revoked before they expire (Delete is a no-op).
+type CookieSessionStore struct {
+	hashKey []byte
+	aead    cipher.AEAD
+}
+
+// NewCookieSessionStore instanciates a new store.
+// The encryption key is optional and must be 16, 24 or 32 bytes long (for AES-128, AES-192 or AES-256).
+func NewCookieSessionStore(hashKey, encryptionKey []byte) (*CookieSessionStore, error) {
+	if len(hashKey) == 0 {
+		return nil, errors.New("missing hash key")
+	}
+	s := &CookieSessionStore{hashKey: hashKey}
+	if len(encryptionKey) > 0 {
+		block, err := aes.NewCipher(encryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("init cipher: %w", err)
+		}
+		s.aead, err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("init GCM: %w", err)
+		}
+	}
+	return s, nil
+}
+
+func (s *CookieSessionStore) Save(data *SessionData) (string, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	if s.aead != nil {
+		nonce := uid.MustNewID(s.aead.NonceSize())
+		raw = s.aead.Seal(nonce, nonce, raw, nil)
+	}
+	v := base64.RawURLEncoding.EncodeToString(raw)
+	v += "." + signValue(s.hashKey, v)
+	if len(v) > 4000 {
+		return "", fmt.Errorf("%w: cookie is %d bytes long", ErrSessionTooLarge, len(v))
+	}
+	return v, nil
+}
+
+func (s *CookieSessionStore) Load(cookieValue string) (*SessionData, error) {
+	v, signature, found := strings.Cut(cookieValue, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(signValue(s.hashKey, v))) {
+		return nil, fmt.Errorf("%w: invalid signature", ErrSessionNotFound)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	if s.aead != nil {
+		if len(raw) < s.aead.NonceSize() {
+			return nil, errors.New("missing nonce")
+		}
+		raw, err = s.aead.Open(nil, raw[:s.aead.NonceSize()], raw[s.aead.NonceSize():], nil)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt: %w", err)
+		}
+	}
+	data := &SessionData{}
+	err = json.Unmarshal(raw, data)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+	return data, nil
+}
+
+func (s *CookieSessionStore) Delete(id string) error { return nil }
+
+// KVSessionStore stores sessions server-side in a kv.DB, the cookie only holds the session ID.
+type KVSessionStore struct {
+	db     *kv.DB
+	prefix string
+}
+
+// NewKVSessionStore instanciates a new store, session keys are prefixed with the given prefix.
+// The prefix is required (so sessions can't be confused with other keys of the database).
+func NewKVSessionStore(db *kv.DB, prefix string) *KVSessionStore {
+	if prefix == "" {
+		panic(errors.New("missing kv session store prefix"))
+	}
+	return &KVSessionStore{db: db, prefix: prefix}
+}
+
+func (s *KVSessionStore) Load(cookieValue string) (*SessionData, error) {
+	if !isSessionID(cookieValue) {
+		return nil, ErrSessionNotFound // don't look up arbitrary keys
+	}
+	k := []byte(s.prefix + cookieValue)
+	s.db.RLock()
+	defer s.db.RUnlock()
+	if !s.db.KeyExists(k) {
+		return nil, ErrSessionNotFound
+	}
+	v, err := s.db.Get(k)
+	if err != nil {
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+	data := &SessionData{}
+	err = json.Unmarshal(v, data)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal session: %w", err)
+	}
+	return data, nil
+}
+
+func (s *KVSessionStore) Save(data *SessionData) (string, error) {
+	v, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	s.db.Lock()
+	defer s.db.Unlock()
+	err = s.db.Put([]byte(s.prefix+data.ID), v)
+	if err != nil {
+		return "", fmt.Errorf("put session: %w", err)
+	}
+	return data.ID, nil
+}
+
+func (s *KVSessionStore) Delete(id string) error {
+	k := []byte(s.prefix + id)
+	s.db.Lock()
+	defer s.db.Unlock()
+	if !s.db.KeyExists(k) {
+		return nil
+	}
+	return s.db.Delete(k)
+}
+
+// DeleteExpired deletes sessions that expired before the given time.
+// Call it periodically to keep the database small.
+func (s *KVSessionStore) DeleteExpired(now time.Time) (int, error) {
+	s.db.Lock()
+	defer s.db.Unlock()
+	expired := [][]byte{}
+	var err error
+	s.db.ForEachKey(func(k []byte) (stop bool) {
+		if !strings.HasPrefix(string(k), s.prefix) {
+			return false
+		}
+		var v []byte
+		v, err = s.db.Get(k)
+		if err != nil {
+			return true
+		}
+		data := &SessionData{}
+		if json.Unmarshal(v, data) != nil || now.After(data.ExpiresAt) {
+			expired = append(expired, append([]byte(nil), k...))
+		}
+		return false
+	})
+	if err != nil {
+		return 0, fmt.Errorf("get session: %w", err)
+	}
+	for i, k := range expired {
+		if err := s.db.Delete(k); err != nil {
+			return i, fmt.Errorf("delete session: %w", err)
+		}
+	}
+	return len(expired), nil
+}
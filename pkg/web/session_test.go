@@ -0,0 +1,166 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ejuju/go-utils/pkg/kv"
+)
+
+func TestSessionMiddleware(t *testing.T) {
+	db, err := kv.NewDB(filepath.Join(t.TempDir(), "test.db"), kv.DefaultFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	signedStore, err := NewCookieSessionStore([]byte("hash-key"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptedStore, err := NewCookieSessionStore([]byte("hash-key"), []byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stores := map[string]SessionStore{
+		"signed cookie":    signedStore,
+		"encrypted cookie": encryptedStore,
+		"kv":               NewKVSessionStore(db, "session/"),
+	}
+	for name, store := range stores {
+		t.Run("can load and save sessions with "+name+" store", func(t *testing.T) {
+			var storeErr error
+			h := SessionMiddleware(&SessionConfig{
+				Store:   store,
+				OnError: func(err error, r *http.Request) { storeErr = err },
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				session := GetSession(r)
+				switch r.URL.Path {
+				case "/login":
+					session.Rotate()
+					session.Set("user", "alice")
+					session.AddFlash("welcome")
+				case "/logout":
+					session.Destroy()
+				}
+				w.Write([]byte(session.Get("user") + " " + strings.Join(session.Flashes(), ",")))
+			}))
+
+			var cookie *http.Cookie
+			do := func(path, want string) *httptest.ResponseRecorder {
+				t.Helper()
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				if cookie != nil {
+					req.AddCookie(cookie)
+				}
+				resrec := httptest.NewRecorder()
+				h.ServeHTTP(resrec, req)
+				if storeErr != nil {
+					t.Fatal(storeErr)
+				}
+				if got := resrec.Body.String(); got != want {
+					t.Fatalf("%s: want body %q but got %q", path, want, got)
+				}
+				if cookies := resrec.Result().Cookies(); len(cookies) > 0 {
+					cookie = cookies[0]
+				}
+				return resrec
+			}
+
+			if resrec := do("/", " "); len(resrec.Result().Cookies()) != 0 {
+				t.Fatal("want no cookie for empty new session")
+			}
+			do("/login", "alice welcome")
+			first := cookie
+			do("/", "alice ")
+			do("/", "alice ")
+
+			// Rotating should invalidate the previous ID (for server-side stores)
+			do("/login", "alice welcome")
+			if first.Value == cookie.Value {
+				t.Fatal("want new cookie value after rotation")
+			}
+			if _, isKV := store.(*KVSessionStore); isKV {
+				if _, err := store.Load(first.Value); err != ErrSessionNotFound {
+					t.Fatalf("want previous session deleted but got %v", err)
+				}
+			}
+
+			resrec := do("/logout", " ")
+			if cookie.MaxAge != -1 {
+				t.Fatalf("want cookie deleted but got max age %d", cookie.MaxAge)
+			}
+			if resrec.Result().Cookies()[0].Value != "" {
+				t.Fatal("want empty cookie value")
+			}
+		})
+	}
+
+	t.Run("should ignore tampered and expired sessions", func(t *testing.T) {
+		h := SessionMiddleware(&SessionConfig{Store: signedStore})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session := GetSession(r)
+			if !session.IsNew() {
+				t.Fatal("want new session")
+			}
+		}))
+		expired, err := signedStore.Save(&SessionData{ID: "abc", ExpiresAt: time.Now().Add(-time.Second)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		valid, err := signedStore.Save(&SessionData{ID: "abc", ExpiresAt: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range []string{expired, "x" + valid, "invalid"} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.AddCookie(&http.Cookie{Name: "session", Value: v})
+			h.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	})
+
+	t.Run("can delete expired sessions from kv store", func(t *testing.T) {
+		store := NewKVSessionStore(db, "expiry/")
+		expiredID, validID := newSessionID(), newSessionID()
+		for _, data := range []*SessionData{
+			{ID: expiredID, ExpiresAt: time.Now().Add(-time.Second)},
+			{ID: validID, ExpiresAt: time.Now().Add(time.Hour)},
+		} {
+			if _, err := store.Save(data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		n, err := store.DeleteExpired(time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Fatalf("want 1 deleted session but got %d", n)
+		}
+		if _, err := store.Load(validID); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("should only load valid session IDs from kv store", func(t *testing.T) {
+		store := NewKVSessionStore(db, "ids/")
+		if err := db.Put([]byte("ids/other"), []byte(`{"id":"other"}`)); err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range []string{"other", "../other", strings.Repeat("z", 64)} {
+			if _, err := store.Load(v); err != ErrSessionNotFound {
+				t.Fatalf("%q: want error %q but got %v", v, ErrSessionNotFound, err)
+			}
+		}
+
+		defer func() {
+			if recover() == nil {
+				t.Fatal("want panic for empty prefix")
+			}
+		}()
+		NewKVSessionStore(db, "")
+	})
+}
@@ -0,0 +1,330 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSitemapURL = errors.New("invalid sitemap URL")
+
+// MaxSitemapURLs is the maximum number of URLs in a sitemap file (as per the sitemaps protocol),
+// bigger sitemaps are split into several files listed in a sitemap index.
+const MaxSitemapURLs = 50000
+
+// SitemapChangeFreq indicates how frequently a page is likely to change.
+type SitemapChangeFreq string
+
+const (
+	SitemapAlways  SitemapChangeFreq = "always"
+	SitemapHourly  SitemapChangeFreq = "hourly"
+	SitemapDaily   SitemapChangeFreq = "daily"
+	SitemapWeekly  SitemapChangeFreq = "weekly"
+	SitemapMonthly SitemapChangeFreq = "monthly"
+	SitemapYearly  SitemapChangeFreq = "yearly"
+	SitemapNever   SitemapChangeFreq = "never"
+)
+
+// SitemapURL represents a page in a sitemap.
+type SitemapURL struct {
+	Loc        string // Path (resolved against the sitemap base URL) or absolute URL
+	LastMod    time.Time
+	ChangeFreq SitemapChangeFreq
+	Priority   float64            // Between 0 and 1 (omitted if 0)
+	Images     []string           // Image paths or URLs
+	Alternates []SitemapAlternate // Localized versions of the page
+}
+
+// SitemapAlternate represents a localized version of a page.
+type SitemapAlternate struct {
+	Lang string // For ex: "fr" or "en-US" or "x-default"
+	Loc  string // Path (resolved against the sitemap base URL) or absolute URL
+}
+
+// AddToSitemap adds the route to sitemaps generated with Sitemap.AddRoutes.
+// For routes in a group, the group prefix is added to the paths.
+func (rh *Route) AddToSitemap(urls ...SitemapURL) *Route {
+	rh.sitemap = append(rh.sitemap, urls...)
+	return rh
+}
+
+// Sitemap builds sitemap XML files.
+type Sitemap struct {
+	baseURL *url.URL
+	urls    []SitemapURL
+	Gzip    bool // Compress files (names should then end with .xml.gz)
+	MaxURLs int  // Maximum number of URLs per file (default and maximum: MaxSitemapURLs)
+}
+
+// NewSitemap instanciates a new sitemap, paths are resolved against the base URL (for ex: "https://example.com").
+func NewSitemap(baseURL string) (*Sitemap, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("base URL must be absolute: %q", baseURL)
+	}
+	return &Sitemap{baseURL: u}, nil
+}
+
+// MustNewSitemap is like NewSitemap but panics on error.
+func MustNewSitemap(baseURL string) *Sitemap {
+	s, err := NewSitemap(baseURL)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Add adds URLs to the sitemap.
+func (s *Sitemap) Add(urls ...SitemapURL) *Sitemap {
+	s.urls = append(s.urls, urls...)
+	return s
+}
+
+// AddRoutes adds the URLs of routes registered with Route.AddToSitemap (including routes of mounted groups).
+func (s *Sitemap) AddRoutes(routes Routes) *Sitemap {
+	return s.Add(routesSitemapURLs(routes, "")...)
+}
+
+func routesSitemapURLs(routes Routes, prefix string) []SitemapURL {
+	out := []SitemapURL{}
+	for _, route := range routes {
+		for _, u := range route.sitemap {
+			if prefix != "" && !isAbsoluteURL(u.Loc) {
+				u.Loc = prefix + u.Loc
+			}
+			out = append(out, u)
+		}
+		if g, ok := route.handler.(*Group); ok {
+			out = append(out, routesSitemapURLs(g.routes, prefix+g.prefix)...)
+		}
+	}
+	return out
+}
+
+func isAbsoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.IsAbs()
+}
+
+// Len returns the number of URLs in the sitemap.
+func (s *Sitemap) Len() int { return len(s.urls) }
+
+// Files returns the sitemap files mapped by path.
+// If the sitemap has more than MaxURLs URLs, the file at the given path is a sitemap index
+// listing files named after it (for ex: "/sitemap.xml" lists "/sitemap-1.xml", "/sitemap-2.xml", etc.).
+func (s *Sitemap) Files(path string) (map[string][]byte, error) {
+	maxURLs := s.MaxURLs
+	if maxURLs <= 0 || maxURLs > MaxSitemapURLs {
+		maxURLs = MaxSitemapURLs
+	}
+	files := map[string][]byte{}
+	if len(s.urls) <= maxURLs {
+		set, err := s.urlSet(s.urls)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := s.encode(set)
+		if err != nil {
+			return nil, err
+		}
+		files[path] = raw
+		return files, nil
+	}
+
+	index := &xmlSitemapIndex{XMLNS: sitemapXMLNS}
+	now := time.Now()
+	for i := 0; i*maxURLs < len(s.urls); i++ {
+		end := (i + 1) * maxURLs
+		if end > len(s.urls) {
+			end = len(s.urls)
+		}
+		urls := s.urls[i*maxURLs : end]
+		set, err := s.urlSet(urls)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := s.encode(set)
+		if err != nil {
+			return nil, err
+		}
+		pagePath := sitemapPagePath(path, i+1)
+		files[pagePath] = raw
+
+		lastMod := time.Time{}
+		for _, u := range urls {
+			if u.LastMod.After(lastMod) {
+				lastMod = u.LastMod
+			}
+		}
+		if lastMod.IsZero() {
+			lastMod = now
+		}
+		index.Sitemaps = append(index.Sitemaps, xmlSitemap{Loc: s.resolve(pagePath), LastMod: formatSitemapTime(lastMod)})
+	}
+	raw, err := s.encode(index)
+	if err != nil {
+		return nil, err
+	}
+	files[path] = raw
+	return files, nil
+}
+
+// Returns the path of the nth file of a split sitemap, for ex: "/sitemap.xml.gz" gives "/sitemap-1.xml.gz".
+func sitemapPagePath(path string, n int) string {
+	ext := ""
+	for _, suffix := range []string{".gz", ".xml"} {
+		if strings.HasSuffix(path, suffix) {
+			path = strings.TrimSuffix(path, suffix)
+			ext = suffix + ext
+		}
+	}
+	return path + "-" + strconv.Itoa(n) + ext
+}
+
+// Handler generates the sitemap files and serves them (use MatchPathPrefix or a pattern to match all files).
+func (s *Sitemap) Handler(path string) (http.Handler, error) {
+	files, err := s.Files(path)
+	if err != nil {
+		return nil, err
+	}
+	contentType := "application/xml; charset=utf-8"
+	if s.Gzip {
+		contentType = "application/gzip"
+	}
+	handlers := map[string]http.Handler{}
+	for fpath, raw := range files {
+		handlers[fpath] = ServeRaw(raw)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, ok := handlers[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		h.ServeHTTP(w, r)
+	}), nil
+}
+
+const sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type xmlURLSet struct {
+	XMLName    xml.Name `xml:"urlset"`
+	XMLNS      string   `xml:"xmlns,attr"`
+	XMLNSImage string   `xml:"xmlns:image,attr,omitempty"`
+	XMLNSXHTML string   `xml:"xmlns:xhtml,attr,omitempty"`
+	URLs       []xmlURL `xml:"url"`
+}
+
+type xmlURL struct {
+	Loc        string         `xml:"loc"`
+	LastMod    string         `xml:"lastmod,omitempty"`
+	ChangeFreq string         `xml:"changefreq,omitempty"`
+	Priority   string         `xml:"priority,omitempty"`
+	Images     []xmlImage     `xml:"image:image"`
+	Alternates []xmlAlternate `xml:"xhtml:link"`
+}
+
+type xmlImage struct {
+	Loc string `xml:"image:loc"`
+}
+
+type xmlAlternate struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+type xmlSitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []xmlSitemap `xml:"sitemap"`
+}
+
+type xmlSitemap struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Returns the XML representation of the URLs.
+func (s *Sitemap) urlSet(urls []SitemapURL) (*xmlURLSet, error) {
+	set := &xmlURLSet{XMLNS: sitemapXMLNS, URLs: make([]xmlURL, 0, len(urls))}
+	for _, u := range urls {
+		if u.Priority < 0 || u.Priority > 1 {
+			return nil, fmt.Errorf("%w: %q: priority must be between 0 and 1 but got %g", ErrInvalidSitemapURL, u.Loc, u.Priority)
+		}
+		item := xmlURL{Loc: s.resolve(u.Loc), ChangeFreq: string(u.ChangeFreq)}
+		if item.Loc == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSitemapURL, u.Loc)
+		}
+		if !u.LastMod.IsZero() {
+			item.LastMod = formatSitemapTime(u.LastMod)
+		}
+		if u.Priority > 0 {
+			item.Priority = strconv.FormatFloat(u.Priority, 'f', 1, 64)
+		}
+		for _, img := range u.Images {
+			item.Images = append(item.Images, xmlImage{Loc: s.resolve(img)})
+			set.XMLNSImage = "http://www.google.com/schemas/sitemap-image/1.1"
+		}
+		for _, alt := range u.Alternates {
+			item.Alternates = append(item.Alternates, xmlAlternate{Rel: "alternate", Hreflang: alt.Lang, Href: s.resolve(alt.Loc)})
+			set.XMLNSXHTML = "http://www.w3.org/1999/xhtml"
+		}
+		set.URLs = append(set.URLs, item)
+	}
+	return set, nil
+}
+
+// Returns the absolute and escaped URL (or an empty string if invalid).
+func (s *Sitemap) resolve(loc string) string {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return ""
+	}
+	return s.baseURL.ResolveReference(u).String()
+}
+
+// Uses the date only if the time is midnight UTC (W3C datetime format).
+func formatSitemapTime(t time.Time) string {
+	if t.UTC().Equal(t.UTC().Truncate(24 * time.Hour)) {
+		return t.UTC().Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (s *Sitemap) encode(v any) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	var w io.Writer = buf
+	var zw *gzip.Writer
+	if s.Gzip {
+		zw = gzip.NewWriter(buf)
+		w = zw
+	}
+	io.WriteString(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	err := enc.Encode(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode sitemap: %w", err)
+	}
+	io.WriteString(w, "\n")
+	if zw != nil {
+		err = zw.Close()
+		if err != nil {
+			return nil, fmt.Errorf("compress sitemap: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,147 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSitemap(t *testing.T) {
+	t.Run("can generate a sitemap with metadata", func(t *testing.T) {
+		s := MustNewSitemap("https://example.com").Add(SitemapURL{
+			Loc:        "/search?q=a&lang=en",
+			LastMod:    time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			ChangeFreq: SitemapWeekly,
+			Priority:   0.8,
+			Images:     []string{"/img/a b.png"},
+			Alternates: []SitemapAlternate{{Lang: "fr", Loc: "https://example.fr/recherche"}},
+		})
+		files, err := s.Files("/sitemap.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := xml.Header + `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+	<url>
+		<loc>https://example.com/search?q=a&amp;lang=en</loc>
+		<lastmod>2024-01-02</lastmod>
+		<changefreq>weekly</changefreq>
+		<priority>0.8</priority>
+		<image:image>
+			<image:loc>https://example.com/img/a%20b.png</image:loc>
+		</image:image>
+		<xhtml:link rel="alternate" hreflang="fr" href="https://example.fr/recherche"></xhtml:link>
+	</url>
+</urlset>
+`
+		if got := string(files["/sitemap.xml"]); got != want {
+			t.Fatalf("want %q but got %q", want, got)
+		}
+	})
+
+	t.Run("can split big sitemaps with an index", func(t *testing.T) {
+		s := MustNewSitemap("https://example.com")
+		s.MaxURLs = 2
+		for i := 0; i < 5; i++ {
+			s.Add(SitemapURL{Loc: "/" + strconv.Itoa(i)})
+		}
+		s.Gzip = true
+		files, err := s.Files("/sitemap.xml.gz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 4 {
+			t.Fatalf("want index and 3 sitemaps but got %d files", len(files))
+		}
+		index := gunzipString(t, files["/sitemap.xml.gz"])
+		for i := 1; i <= 3; i++ {
+			path := "/sitemap-" + strconv.Itoa(i) + ".xml.gz"
+			if !strings.Contains(index, "<loc>https://example.com"+path+"</loc>") {
+				t.Fatalf("want %q in index but got %q", path, index)
+			}
+			if _, ok := files[path]; !ok {
+				t.Fatalf("want file %q", path)
+			}
+		}
+		if last := gunzipString(t, files["/sitemap-3.xml.gz"]); !strings.Contains(last, "https://example.com/4") {
+			t.Fatalf("want last URL in last file but got %q", last)
+		}
+	})
+
+	t.Run("can generate a sitemap from routes", func(t *testing.T) {
+		routes := Routes{}
+		h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+		routes.Handle(h, MatchPath("/")).AddToSitemap(SitemapURL{Loc: "/", Priority: 1})
+		routes.Handle(h, MatchPath("/private"))
+		blog := NewGroup("/blog")
+		blog.Handle(h, MatchPath("/first-post")).AddToSitemap(SitemapURL{Loc: "/first-post"})
+		routes.Mount(blog)
+
+		handler, err := MustNewSitemap("https://example.com").AddRoutes(routes).Handler("/sitemap.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resrec := httptest.NewRecorder()
+		handler.ServeHTTP(resrec, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+		got := resrec.Body.String()
+		if resrec.Header().Get("Content-Type") != "application/xml; charset=utf-8" {
+			t.Fatalf("want XML content type but got %q", resrec.Header().Get("Content-Type"))
+		}
+		for _, want := range []string{"<loc>https://example.com/</loc>", "<loc>https://example.com/blog/first-post</loc>"} {
+			if !strings.Contains(got, want) {
+				t.Fatalf("want %q in sitemap but got %q", want, got)
+			}
+		}
+		if strings.Contains(got, "private") {
+			t.Fatalf("want no private route in sitemap but got %q", got)
+		}
+	})
+
+	t.Run("should reject invalid priority", func(t *testing.T) {
+		_, err := MustNewSitemap("https://example.com").Add(SitemapURL{Loc: "/", Priority: 2}).Files("/sitemap.xml")
+		if !errors.Is(err, ErrInvalidSitemapURL) {
+			t.Fatalf("want invalid URL error but got %v", err)
+		}
+	})
+
+	t.Run("should escape legacy sitemap URLs", func(t *testing.T) {
+		got := SitemapXML("example.com", "/a&b")
+		if !strings.Contains(got, "<loc>https://example.com/a&amp;b</loc>") || !strings.Contains(got, "<priority>1.0</priority>") {
+			t.Fatalf("want escaped URL with priority but got %q", got)
+		}
+	})
+
+	t.Run("should not panic or split legacy sitemaps", func(t *testing.T) {
+		routes := make([]string, MaxSitemapURLs+1)
+		for i := range routes {
+			routes[i] = "/" + strconv.Itoa(i)
+		}
+		got := SitemapXML("example.com", routes...)
+		if strings.Contains(got, "<sitemapindex") || !strings.Contains(got, "<loc>https://example.com/50000</loc>") {
+			t.Fatal("want all URLs in a single file")
+		}
+		if got := SitemapXML("", "/%zz"); !strings.Contains(got, "<loc>https:///%zz</loc>") {
+			t.Fatalf("want URL as given but got %q", got)
+		}
+	})
+}
+
+func gunzipString(t *testing.T, raw []byte) string {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
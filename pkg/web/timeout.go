@@ -0,0 +1,104 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout middleware attaches a deadline to the request context and
+// calls onTimeout (or responds with 503 Service Unavailable if nil) if the handler doesn't finish in time.
+//
+// The handler response is buffered and only sent once the handler returns,
+// writes after the timeout fail with http.ErrHandlerTimeout and are discarded.
+// Panics in the handler are propagated so they can be caught by PanicRecoveryMiddleware.
+//
+// Use it in a Group to set a different duration for each group of routes.
+func TimeoutMiddleware(dur time.Duration, onTimeout http.Handler) func(http.Handler) http.Handler {
+	if onTimeout == nil {
+		onTimeout = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		})
+	}
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), dur)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicc := make(chan any, 1)
+			go func() {
+				defer func() {
+					if err := recover(); err != nil {
+						panicc <- err
+					}
+				}()
+				h.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case err := <-panicc:
+				panic(err)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				for k, v := range tw.header {
+					w.Header()[k] = v
+				}
+				if tw.statusCode == 0 {
+					tw.statusCode = http.StatusOK
+				}
+				w.WriteHeader(tw.statusCode)
+				w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				if ctx.Err() == context.DeadlineExceeded {
+					onTimeout.ServeHTTP(w, r)
+				}
+			}
+		})
+	}
+}
+
+// Buffers the response until the handler returns or times out.
+type timeoutWriter struct {
+	mu         sync.Mutex
+	header     http.Header
+	buf        bytes.Buffer
+	statusCode int
+	timedOut   bool
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.header }
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.statusCode == 0 {
+		tw.statusCode = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(statusCode int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.statusCode != 0 {
+		return
+	}
+	if statusCode < 100 || statusCode > 999 {
+		panic(fmt.Sprintf("invalid status code %d", statusCode))
+	}
+	tw.statusCode = statusCode
+}
@@ -0,0 +1,62 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	t.Run("should send the handler response if it finishes in time", func(t *testing.T) {
+		h := Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := r.Context().Deadline(); !ok {
+				t.Error("want deadline set on request context")
+			}
+			w.Header().Set("X-Test", "ok")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("hello"))
+		}), TimeoutMiddleware(time.Second, nil))
+
+		resrec := httptest.NewRecorder()
+		h.ServeHTTP(resrec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if resrec.Code != http.StatusCreated || resrec.Body.String() != "hello" || resrec.Header().Get("X-Test") != "ok" {
+			t.Fatalf("want 201 %q with header but got %d %q", "hello", resrec.Code, resrec.Body.String())
+		}
+	})
+
+	t.Run("should call onTimeout and discard late writes", func(t *testing.T) {
+		writeErrc := make(chan error, 1)
+		h := Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			time.Sleep(10 * time.Millisecond)
+			_, err := w.Write([]byte("too late"))
+			writeErrc <- err
+		}), TimeoutMiddleware(10*time.Millisecond, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("timeout"))
+		})))
+
+		resrec := httptest.NewRecorder()
+		h.ServeHTTP(resrec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if resrec.Code != http.StatusServiceUnavailable || resrec.Body.String() != "timeout" {
+			t.Fatalf("want 503 %q but got %d %q", "timeout", resrec.Code, resrec.Body.String())
+		}
+		if err := <-writeErrc; !errors.Is(err, http.ErrHandlerTimeout) {
+			t.Fatalf("want http.ErrHandlerTimeout but got %v", err)
+		}
+	})
+
+	t.Run("should propagate panics", func(t *testing.T) {
+		recovered := any(nil)
+		h := Wrap(
+			Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("oops") }), TimeoutMiddleware(time.Second, nil)),
+			PanicRecoveryMiddleware(func(err any, w http.ResponseWriter, r *http.Request) { recovered = err }),
+		)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		if recovered != "oops" {
+			t.Fatalf("want recovered panic %q but got %v", "oops", recovered)
+		}
+	})
+}
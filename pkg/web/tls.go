@@ -0,0 +1,192 @@
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NewTLSServerWithDefaults is like NewServerWithDefaults but with the given TLS config
+// (Run starts it with ServeTLS).
+func NewTLSServerWithDefaults(h http.Handler, port int, tlsConfig *tls.Config) *http.Server {
+	s := NewServerWithDefaults(h, port)
+	s.TLSConfig = tlsConfig
+	return s
+}
+
+// NewTLSConfig returns a TLS config with sane defaults using the given certificate (and TLS 1.2 as minimum version).
+func NewTLSConfig(cert tls.Certificate) *tls.Config {
+	return &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}
+}
+
+// SelfSignedCertificate generates a self-signed certificate valid for one year for the given hosts
+// (DNS names or IP addresses), defaults to "localhost", "127.0.0.1" and "::1".
+// Only use it for development purposes.
+func SelfSignedCertificate(hosts ...string) (tls.Certificate, error) {
+	if len(hosts) == 0 {
+		hosts = []string{"localhost", "127.0.0.1", "::1"}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("generate serial number: %w", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Self-signed development certificate"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("create certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("parse certificate: %w", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
+
+// CertificateReloader loads a certificate and key pair from disk
+// and reloads it when one of the files changes (checked at most once per CheckInterval).
+// Use GetCertificate as tls.Config.GetCertificate.
+type CertificateReloader struct {
+	certFile      string
+	keyFile       string
+	CheckInterval time.Duration // Default: 10s
+
+	mu          sync.Mutex
+	cert        *tls.Certificate
+	modTime     time.Time
+	lastChecked time.Time
+}
+
+// NewCertificateReloader loads the certificate and key pair from disk and fails if they can't be loaded.
+func NewCertificateReloader(certFile, keyFile string) (*CertificateReloader, error) {
+	cr := &CertificateReloader{certFile: certFile, keyFile: keyFile, CheckInterval: 10 * time.Second}
+	err := cr.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// Reload loads the certificate and key pair from disk.
+func (cr *CertificateReloader) Reload() error {
+	modTime, err := cr.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair: %w", err)
+	}
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.cert, cr.modTime, cr.lastChecked = &cert, modTime, time.Now()
+	return nil
+}
+
+// GetCertificate returns the current certificate, reloading it first if the files changed.
+// If reloading fails, the previous certificate is kept.
+func (cr *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.mu.Lock()
+	shouldCheck := time.Since(cr.lastChecked) >= cr.CheckInterval
+	if shouldCheck {
+		cr.lastChecked = time.Now()
+	}
+	cert, modTime := cr.cert, cr.modTime
+	cr.mu.Unlock()
+
+	if shouldCheck {
+		if latest, err := cr.latestModTime(); err == nil && latest.After(modTime) {
+			if err := cr.Reload(); err == nil {
+				cr.mu.Lock()
+				cert = cr.cert
+				cr.mu.Unlock()
+			}
+		}
+	}
+	return cert, nil
+}
+
+// Returns the latest modification time of the certificate and key files.
+func (cr *CertificateReloader) latestModTime() (time.Time, error) {
+	latest := time.Time{}
+	for _, fpath := range []string{cr.certFile, cr.keyFile} {
+		info, err := os.Stat(fpath)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// HTTPS redirect handler permanently redirects requests to the same URL with the HTTPS scheme.
+// The port is omitted if httpsPort is 443 or 0.
+// Use it as handler of a plain HTTP server next to the HTTPS one.
+func HTTPSRedirectHandler(httpsPort int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]") // IPv6 address without port
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]" // IPv6 address
+		}
+		if httpsPort != 0 && httpsPort != 443 {
+			host += ":" + strconv.Itoa(httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	}
+}
+
+// HSTS middleware sets the Strict-Transport-Security header on responses to HTTPS requests.
+func HSTSMiddleware(maxAge time.Duration, includeSubdomains, preload bool) func(http.Handler) http.Handler {
+	value := "max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+	if includeSubdomains {
+		value += "; includeSubDomains"
+	}
+	if preload {
+		value += "; preload"
+	}
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS != nil {
+				w.Header().Set("Strict-Transport-Security", value)
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
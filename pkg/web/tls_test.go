@@ -0,0 +1,105 @@
+package web
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTLS(t *testing.T) {
+	t.Run("can serve HTTPS with a self-signed certificate and HSTS", func(t *testing.T) {
+		cert, err := SelfSignedCertificate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), HSTSMiddleware(365*24*time.Hour, true, false))
+		s := httptest.NewUnstartedServer(h)
+		s.TLS = NewTLSConfig(cert)
+		s.StartTLS()
+		defer s.Close()
+
+		pool := x509.NewCertPool()
+		pool.AddCert(cert.Leaf)
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+		res, err := client.Get(s.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		want := "max-age=31536000; includeSubDomains"
+		if got := res.Header.Get("Strict-Transport-Security"); got != want {
+			t.Fatalf("want HSTS header %q but got %q", want, got)
+		}
+	})
+
+	t.Run("can reload a certificate when files change", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+		writeCert := func(modTime time.Time) *x509.Certificate {
+			cert, err := SelfSignedCertificate("example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+			keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})
+			for fpath, raw := range map[string][]byte{certFile: certPEM, keyFile: keyPEM} {
+				if err := os.WriteFile(fpath, raw, 0600); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(fpath, modTime, modTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+			return cert.Leaf
+		}
+
+		first := writeCert(time.Now().Add(-time.Hour))
+		cr, err := NewCertificateReloader(certFile, keyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cr.CheckInterval = 0
+		got, _ := cr.GetCertificate(nil)
+		if !bytes.Equal(got.Certificate[0], first.Raw) {
+			t.Fatal("want first certificate")
+		}
+
+		second := writeCert(time.Now())
+		got, _ = cr.GetCertificate(nil)
+		if !bytes.Equal(got.Certificate[0], second.Raw) {
+			t.Fatal("want reloaded certificate")
+		}
+	})
+
+	t.Run("can redirect HTTP requests to HTTPS", func(t *testing.T) {
+		tests := []struct {
+			port   int
+			target string
+			want   string
+		}{
+			{port: 443, target: "http://example.com/path?q=1", want: "https://example.com/path?q=1"},
+			{port: 8443, target: "http://example.com:8080/path", want: "https://example.com:8443/path"},
+			{port: 0, target: "http://[::1]:8080/", want: "https://[::1]/"},
+			{port: 0, target: "http://[::1]/path", want: "https://[::1]/path"},
+			{port: 8443, target: "http://[::1]/", want: "https://[::1]:8443/"},
+		}
+		for _, test := range tests {
+			resrec := httptest.NewRecorder()
+			HTTPSRedirectHandler(test.port).ServeHTTP(resrec, httptest.NewRequest(http.MethodGet, test.target, nil))
+			if resrec.Code != http.StatusPermanentRedirect || resrec.Header().Get("Location") != test.want {
+				t.Fatalf("%q: want redirect to %q but got %d %q", test.target, test.want, resrec.Code, resrec.Header().Get("Location"))
+			}
+		}
+	})
+}
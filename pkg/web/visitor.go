@@ -0,0 +1,152 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ejuju/go-utils/pkg/uid"
+)
+
+var ErrInvalidSaltFile = errors.New("invalid salt file")
+
+// SaltStore holds the secret salt of the current day.
+// When the day changes, a new random salt replaces the previous one,
+// so visitor hashes of previous days can't be linked or reversed anymore.
+//
+// Implementations must destroy previous salts,
+// an append-only store (like kv.DB) can't be used since it keeps them in its file.
+type SaltStore interface {
+	Salt(day string) ([]byte, error)
+}
+
+// MemorySaltStore keeps the salt in memory (a new salt is generated on restart).
+type MemorySaltStore struct {
+	mu   sync.Mutex
+	day  string
+	salt []byte
+}
+
+func NewMemorySaltStore() *MemorySaltStore { return &MemorySaltStore{} }
+
+func (s *MemorySaltStore) Salt(day string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.day != day {
+		s.day, s.salt = day, uid.MustNewID(32)
+	}
+	return s.salt, nil
+}
+
+// FileSaltStore keeps the salt in a file so visitor hashes stay the same after a restart.
+// The file only holds the salt of the current day: it is overwritten in place when the day changes.
+type FileSaltStore struct {
+	mu   sync.Mutex
+	path string
+	day  string
+	salt []byte
+}
+
+func NewFileSaltStore(path string) *FileSaltStore { return &FileSaltStore{path: path} }
+
+func (s *FileSaltStore) Salt(day string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.day == day {
+		return s.salt, nil
+	}
+
+	// Use the salt from the file if it was saved for the same day
+	raw, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read salt file: %w", err)
+	}
+	if len(raw) > 0 {
+		fileDay, hexSalt, found := strings.Cut(strings.TrimSpace(string(raw)), " ")
+		salt, err := hex.DecodeString(hexSalt)
+		if !found || err != nil || len(salt) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidSaltFile, s.path)
+		}
+		if fileDay == day {
+			s.day, s.salt = day, salt
+			return s.salt, nil
+		}
+	}
+
+	// Otherwise replace the previous salt with a new one
+	salt := uid.MustNewID(32)
+	if err := overwriteFile(s.path, []byte(day+" "+hex.EncodeToString(salt)+"\n")); err != nil {
+		return nil, fmt.Errorf("write salt file: %w", err)
+	}
+	s.day, s.salt = day, salt
+	return s.salt, nil
+}
+
+// Writes the content over the previous one (instead of creating a new file)
+// so the previous content doesn't remain on disk, and syncs the file.
+func overwriteFile(path string, content []byte) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteAt(content, 0)
+	if err == nil {
+		err = f.Truncate(int64(len(content)))
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// VisitorHasher identifies visitors without storing personal data,
+// it can be used to count unique visitors per day.
+//
+// The hash is a HMAC of the client IP address and user-agent using a secret salt that changes every day (UTC):
+// the same visitor gets the same hash during the day and a different one the next day,
+// and hashes can't be reversed once the salt is gone.
+type VisitorHasher struct {
+	salts    SaltStore
+	clientIP *ClientIPResolver
+	now      func() time.Time
+}
+
+// NewVisitorHasher instanciates a new hasher.
+// Salts default to a new MemorySaltStore and client IP addresses to DefaultClientIPResolver.
+func NewVisitorHasher(salts SaltStore, clientIP *ClientIPResolver) *VisitorHasher {
+	if salts == nil {
+		salts = NewMemorySaltStore()
+	}
+	if clientIP == nil {
+		clientIP = DefaultClientIPResolver
+	}
+	return &VisitorHasher{salts: salts, clientIP: clientIP, now: time.Now}
+}
+
+// Hash returns the visitor hash for the request.
+func (vh *VisitorHasher) Hash(r *http.Request) (string, error) {
+	ip, err := vh.clientIP.ClientIP(r)
+	if err != nil {
+		return "", fmt.Errorf("resolve client IP: %w", err)
+	}
+	day := vh.now().UTC().Format("2006-01-02")
+	salt, err := vh.salts.Salt(day)
+	if err != nil {
+		return "", fmt.Errorf("get salt: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(day + "\x00" + ip.String() + "\x00" + r.UserAgent()))
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(mac.Sum(nil)[:20]), nil
+}
@@ -0,0 +1,111 @@
+package web
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVisitorHasher(t *testing.T) {
+	t.Run("can hash visitors per day", func(t *testing.T) {
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		vh := NewVisitorHasher(nil, nil)
+		vh.now = func() time.Time { return now }
+		newRequest := func(remoteAddr, userAgent string) *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set("User-Agent", userAgent)
+			return req
+		}
+		hash := func(r *http.Request) string {
+			h, err := vh.Hash(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return h
+		}
+
+		first := hash(newRequest("192.0.2.1:1234", "agent"))
+		if got := hash(newRequest("192.0.2.1:5678", "agent")); got != first {
+			t.Fatalf("want same hash for same visitor but got %q and %q", first, got)
+		}
+		if got := hash(newRequest("192.0.2.2:1234", "agent")); got == first {
+			t.Fatal("want different hash for different IP")
+		}
+		if got := hash(newRequest("192.0.2.1:1234", "other")); got == first {
+			t.Fatal("want different hash for different user-agent")
+		}
+
+		now = now.Add(24 * time.Hour)
+		if got := hash(newRequest("192.0.2.1:1234", "agent")); got == first {
+			t.Fatal("want different hash on the next day")
+		}
+	})
+
+	t.Run("should return an error for invalid client IP", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "invalid"
+		if _, err := NewVisitorHasher(nil, nil).Hash(req); err == nil {
+			t.Fatal("want error")
+		}
+	})
+}
+
+func TestFileSaltStore(t *testing.T) {
+	t.Run("can keep the salt of the day across restarts", func(t *testing.T) {
+		fpath := filepath.Join(t.TempDir(), "salt")
+		first, err := NewFileSaltStore(fpath).Salt("2024-01-01")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := NewFileSaltStore(fpath).Salt("2024-01-01")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, first) {
+			t.Fatalf("want %x but got %x", first, got)
+		}
+	})
+
+	t.Run("should destroy the previous salt when the day changes", func(t *testing.T) {
+		fpath := filepath.Join(t.TempDir(), "salt")
+		store := NewFileSaltStore(fpath)
+		first, err := store.Salt("2024-01-01")
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := store.Salt("2024-01-02")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(first, second) {
+			t.Fatal("want different salt on the next day")
+		}
+		raw, err := os.ReadFile(fpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(raw, []byte(hex.EncodeToString(first))) {
+			t.Fatal("want previous salt to be removed from the file")
+		}
+		if got, want := string(raw), "2024-01-02 "+hex.EncodeToString(second)+"\n"; got != want {
+			t.Fatalf("want %q but got %q", want, got)
+		}
+	})
+
+	t.Run("should return an error for an invalid file", func(t *testing.T) {
+		fpath := filepath.Join(t.TempDir(), "salt")
+		if err := os.WriteFile(fpath, []byte("invalid"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewFileSaltStore(fpath).Salt("2024-01-01"); !errors.Is(err, ErrInvalidSaltFile) {
+			t.Fatalf("want error %v but got %v", ErrInvalidSaltFile, err)
+		}
+	})
+}
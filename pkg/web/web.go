@@ -1,47 +1,15 @@
 package web
 
 import (
-	"context"
+	"bytes"
 	"crypto/sha1"
 	"encoding/base32"
-	"fmt"
 	"net"
 	"net/http"
 	"os"
-	"os/signal"
-	"strconv"
-	"syscall"
 	"time"
-
-	"github.com/ejuju/go-utils/pkg/logs"
 )
 
-// Access logging middleware logs incoming HTTP requests
-func AccessLoggingMiddleware(logger logs.Logger) func(http.Handler) http.Handler {
-	return func(h http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			resrec := &ResponseStatusRecorder{ResponseWriter: w} // use custom response writer to record status
-			before := time.Now()                                 // record timestamp before request is handled
-			h.ServeHTTP(resrec, r)                               //
-			dur := time.Since(before)                            // calculate duration to handle request
-
-			// Log
-			logstr := fmt.Sprintf("%d %-4s %5dμs %s", resrec.StatusCode, r.Method, dur.Microseconds(), r.URL.Path)
-			logger.Log(logstr)
-		})
-	}
-}
-
-type ResponseStatusRecorder struct {
-	http.ResponseWriter
-	StatusCode int
-}
-
-func (srec *ResponseStatusRecorder) WriteHeader(statusCode int) {
-	srec.StatusCode = statusCode
-	srec.ResponseWriter.WriteHeader(statusCode)
-}
-
 type PanicHandler func(err any, w http.ResponseWriter, r *http.Request)
 
 // Panic recovery middleware logs the recovered error and executes the onPanic callback function.
@@ -61,102 +29,72 @@ func PanicRecoveryMiddleware(onPanic PanicHandler) func(h http.Handler) http.Han
 	}
 }
 
-func NewServerWithDefaults(h http.Handler, port int) *http.Server {
-	out := &http.Server{
-		Addr:              ":" + strconv.Itoa(port),
-		Handler:           h,
-		ReadTimeout:       30 * time.Second,
-		WriteTimeout:      30 * time.Second,
-		IdleTimeout:       30 * time.Second,
-		ReadHeaderTimeout: 30 * time.Second,
-		MaxHeaderBytes:    8000,
-	}
-	return out
-}
-
-// Listens for incoming connections and
-// await interrupt signal (or server error) for graceful shutdown
-func RunServer(s *http.Server, onShutown func() error) error {
-	// Start HTTP server in seperate goroutine
-	errc := make(chan error, 1)
-	go func() { errc <- s.ListenAndServe() }()
-
-	// Await interrupt signal (or server error) for graceful shutdown
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-	select {
-	case <-done:
-		if onShutown != nil {
-			err := onShutown()
-			if err != nil {
-				panic(err)
-			}
-		}
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-		defer cancel()
-		shutdownErr := s.Shutdown(ctx)
-		if shutdownErr != nil {
-			panic(shutdownErr)
-		}
-	case err := <-errc:
-		return err
-	}
-	return nil
-}
-
 func PermanentRedirectHandler(toURL string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, toURL, http.StatusPermanentRedirect) }
 }
 
+// IPAddressFromRequest returns the remote address of the request,
+// or the client address found in the X-Forwarded-For header if useXForwardedFor is set (trusting any proxy).
+// It returns nil if the address is invalid.
+//
+// Use a ClientIPResolver to only trust known proxies.
 func IPAddressFromRequest(r *http.Request, useXForwardedFor bool) net.IP {
-	// get true IP
-	ipAddr := ""
+	res := DefaultClientIPResolver
 	if useXForwardedFor {
-		ipAddr = r.Header.Get("X-Forwarded-For")
-	}
-	if ipAddr == "" {
-		ipAddr = r.RemoteAddr
-		var err error
-		ipAddr, _, err = net.SplitHostPort(ipAddr)
-		if err != nil {
-			panic(err)
-		}
+		res = trustAllXForwardedFor
 	}
-	return net.ParseIP(ipAddr)
+	ip, _ := res.ClientIP(r)
+	return ip
+}
+
+var trustAllXForwardedFor = &ClientIPResolver{
+	TrustedProxies: MustNewClientIPResolver("0.0.0.0/0", "::/0").TrustedProxies,
+	Header:         HeaderXForwardedFor,
 }
 
+// VisitorHash hashes the client IP address and user-agent.
+//
+// Deprecated: the hash is unsalted, so it can be reversed (by trying all IPv4 addresses)
+// and never changes. Use VisitorHasher instead.
 func VisitorHash(r *http.Request, checkXForwardedFor bool) string {
 	// Hash IP addr and user-agent
 	hash := sha1.New()
-	_, err := hash.Write([]byte(IPAddressFromRequest(r, checkXForwardedFor).String() + r.UserAgent()))
-	if err != nil {
-		panic(err)
-	}
+	hash.Write([]byte(IPAddressFromRequest(r, checkXForwardedFor).String() + r.UserAgent()))
 
 	// Return base32 hex encoded hash
 	return base32.StdEncoding.EncodeToString(hash.Sum(nil))
 }
 
+// ReadAndServeFile reads the file once and serves it like ServeRaw (with its modification time as Last-Modified).
 func ReadAndServeFile(path string) http.HandlerFunc {
 	raw, err := os.ReadFile(path)
 	if err != nil {
 		panic(err)
 	}
-
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", http.DetectContentType(raw))
-		w.WriteHeader(http.StatusOK)
-		w.Write(raw)
+	info, err := os.Stat(path)
+	if err != nil {
+		panic(err)
 	}
+	return serveContent(raw, info.ModTime())
 }
 
-func ServeRaw(v []byte) http.HandlerFunc {
+// ServeRaw serves the given content with a strong ETag.
+// It answers conditional requests (304 Not Modified) and range requests (206 Partial Content).
+// The content type is detected from the content if not set.
+//
+// Use CacheMiddleware to set the Cache-Control header, for ex:
+//
+//	web.Wrap(web.ServeRaw(v), web.CacheMiddleware(web.CachePolicy{MaxAge: time.Hour}))
+func ServeRaw(v []byte) http.HandlerFunc { return serveContent(v, time.Time{}) }
+
+func serveContent(v []byte, modTime time.Time) http.HandlerFunc {
+	etag := StrongETag(v)
 	return func(w http.ResponseWriter, r *http.Request) {
 		if w.Header().Get("Content-Type") == "" {
 			w.Header().Set("Content-Type", http.DetectContentType(v))
 		}
-		w.WriteHeader(http.StatusOK)
-		w.Write(v)
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "", modTime, bytes.NewReader(v))
 	}
 }
 
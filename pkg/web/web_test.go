@@ -26,4 +26,21 @@ func TestIPAddressFromRequest(t *testing.T) {
 			t.Fatalf("want %s but got %s", xForwardedFor, ipAddr)
 		}
 	})
+
+	t.Run("should handle X-Forwarded-For lists and addresses without port", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Forwarded-For", "203.0.113.1, 10.0.0.1")
+		if ipAddr := IPAddressFromRequest(req, true); ipAddr.String() != "203.0.113.1" {
+			t.Fatalf("want %s but got %s", "203.0.113.1", ipAddr)
+		}
+
+		req.RemoteAddr = "127.0.0.1"
+		if ipAddr := IPAddressFromRequest(req, false); ipAddr.String() != "127.0.0.1" {
+			t.Fatalf("want %s but got %s", "127.0.0.1", ipAddr)
+		}
+		req.RemoteAddr = "invalid"
+		if ipAddr := IPAddressFromRequest(req, false); ipAddr != nil {
+			t.Fatalf("want nil but got %s", ipAddr)
+		}
+	})
 }
@@ -108,6 +108,7 @@ func AttrIs(v string) [2]string              { return [2]string{"is", v} }
 func AttrAction(v string) [2]string      { return [2]string{"action", v} }
 func AttrRel(v string) [2]string         { return [2]string{"rel", v} }
 func AttrHref(v string) [2]string        { return [2]string{"href", v} }
+func AttrSrc(v string) [2]string         { return [2]string{"src", v} }
 func AttrContent(v string) [2]string     { return [2]string{"content", v} }
 func AttrMethod(v string) [2]string      { return [2]string{"method", v} }
 func AttrOnclick(v string) [2]string     { return [2]string{"onclick", v} }
@@ -115,4 +116,5 @@ func AttrName(v string) [2]string        { return [2]string{"name", v} }
 func AttrType(v string) [2]string        { return [2]string{"type", v} }
 func AttrValue(v string) [2]string       { return [2]string{"value", v} }
 func AttrPlaceholder(v string) [2]string { return [2]string{"placeholder", v} }
+func AttrNonce(v string) [2]string       { return [2]string{"nonce", v} }
 func AttrAriaCurrent() [2]string         { return [2]string{"aria-current", ""} }
@@ -1,6 +1,7 @@
 package wui
 
 import (
+	"context"
 	"strings"
 
 	"github.com/ejuju/go-utils/pkg/cssg"
@@ -24,6 +25,17 @@ func NewFavicon(url string) *htmlg.Element {
 	return Link(htmlg.SetAttrs(AttrRel("icon"), AttrHref(url)))
 }
 
+// NewStylesheet creates a new <link> element with the rel stylesheet attribute and href set to url.
+// Use web.Assets.URL to get the fingerprinted URL of embedded assets.
+func NewStylesheet(url string) *htmlg.Element {
+	return Link(htmlg.SetAttrs(AttrRel("stylesheet"), AttrHref(url)))
+}
+
+// NewExternalScript creates a new <script> element with src set to url.
+func NewExternalScript(url string, opts ...htmlg.Modifier) *htmlg.Element {
+	return Script(htmlg.SetAttr(AttrSrc(url))).Apply(opts...)
+}
+
 func NewMeta(name, content string) *htmlg.Element {
 	return Meta(htmlg.SetAttrs(AttrName(name), AttrContent(content)))
 }
@@ -33,8 +45,15 @@ func NewMetaDescription(desc string) *htmlg.Element { return NewMeta("descriptio
 func NewMetaColorScheme(s string) *htmlg.Element    { return NewMeta("color-scheme", s) }
 
 // NewGlobalStyle creates a new <style> element with the provided rules.
-func NewGlobalStyle(rules cssg.RuleGroup) *htmlg.Element {
-	return Style(htmlg.Wrap(htmlg.String(" " + rules.CSSString())))
+// Add htmlg.SetCSPNonce(r.Context()) to set the Content-Security-Policy nonce.
+func NewGlobalStyle(rules cssg.RuleGroup, opts ...htmlg.Modifier) *htmlg.Element {
+	return Style(htmlg.Wrap(htmlg.String(" " + rules.CSSString()))).Apply(opts...)
+}
+
+// NewInlineScript creates a new <script> element with the given JavaScript code
+// and the Content-Security-Policy nonce found in the context (if any).
+func NewInlineScript(ctx context.Context, js string) *htmlg.Element {
+	return Script(htmlg.Wrap(htmlg.String(js)), htmlg.SetCSPNonce(ctx))
 }
 
 func NewHyperlink(anchor, href string) *htmlg.Element {
@@ -60,8 +79,10 @@ func NewInputSubmit(txt string) *htmlg.Element {
 	return Input(htmlg.SetAttrs(AttrType("submit"), AttrValue(txt)))
 }
 
-func NewForm(action string, method string) *htmlg.Element {
-	return Form(htmlg.SetAttrs(AttrAction(action), AttrMethod(method)))
+// NewForm creates a new <form> element.
+// Add htmlg.AddCSRFTokenInput(r.Context()) to include the CSRF token.
+func NewForm(action string, method string, opts ...htmlg.Modifier) *htmlg.Element {
+	return Form(htmlg.SetAttrs(AttrAction(action), AttrMethod(method))).Apply(opts...)
 }
 
 func NewButton(text string, onclick string) *htmlg.Element {
@@ -1,14 +1,17 @@
 package wui
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
+	"github.com/ejuju/go-utils/pkg/cssg"
 	"github.com/ejuju/go-utils/pkg/htmlg"
 )
 
 func TestHTMLGeneration(t *testing.T) {
 	t.Run("can generate valid HTML elements", func(t *testing.T) {
+		nonceCtx := htmlg.ContextWithCSPNonce(context.Background(), "abc")
 		tests := []struct {
 			description    string
 			input          htmlg.Stringer
@@ -24,6 +27,16 @@ func TestHTMLGeneration(t *testing.T) {
 				input:          NewFavicon("/favicon.ico"),
 				expectedOutput: `<link rel="icon" href="/favicon.ico">`,
 			},
+			{
+				description:    "can generate a valid stylesheet link",
+				input:          NewStylesheet("/static/main.css"),
+				expectedOutput: `<link rel="stylesheet" href="/static/main.css">`,
+			},
+			{
+				description:    "can generate a valid external script",
+				input:          NewExternalScript("/static/main.js", htmlg.SetAttr([2]string{"defer", ""})),
+				expectedOutput: `<script src="/static/main.js" defer=""></script>`,
+			},
 			{
 				description:    "can generate a valid title",
 				input:          NewTitle("MyTitle"),
@@ -39,6 +52,21 @@ func TestHTMLGeneration(t *testing.T) {
 				input:          NewForm("/endpoint", http.MethodPost),
 				expectedOutput: `<form action="/endpoint" method="POST"></form>`,
 			},
+			{
+				description:    "can generate an inline script with the CSP nonce",
+				input:          NewInlineScript(nonceCtx, "console.log(1)"),
+				expectedOutput: `<script nonce="abc">console.log(1)</script>`,
+			},
+			{
+				description:    "can generate an inline script without CSP nonce",
+				input:          NewInlineScript(context.Background(), "console.log(1)"),
+				expectedOutput: `<script>console.log(1)</script>`,
+			},
+			{
+				description:    "can generate a global style with the CSP nonce",
+				input:          NewGlobalStyle(cssg.RuleGroup{cssg.NewRule("body")}, htmlg.SetCSPNonce(nonceCtx)),
+				expectedOutput: `<style nonce="abc"> body {  }</style>`,
+			},
 		}
 
 		for _, test := range tests {